@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// accessListResult eth_createAccessList response
+type accessListResult struct {
+	AccessList types.AccessList `json:"accessList"`
+	GasUsed    hexutil.Uint64   `json:"gasUsed"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// createAccessList ask the geth or parity backend for the access list of the call,
+// gas used is measured with the access list and is taken as gas limit
+func createAccessList(node, from, to string, value *big.Int, data []byte) (types.AccessList, uint64, error) {
+	if node != "geth" && node != "parity" {
+		return nil, 0, errors.New("eth_createAccessList only support geth, parity")
+	}
+	client, err := rpc.Dial(nodeRPC(node))
+	if err != nil {
+		return nil, 0, errors.New(strings.Join([]string{"node error", err.Error()}, " "))
+	}
+	defer client.Close()
+
+	// no fee fields, the call is free of gas cost so the whole balance can be the value
+	arg := map[string]interface{}{
+		"from":  common.HexToAddress(from),
+		"to":    common.HexToAddress(to),
+		"value": (*hexutil.Big)(value),
+	}
+	if len(data) > 0 {
+		arg["data"] = hexutil.Bytes(data)
+	}
+
+	var result accessListResult
+	if err := client.CallContext(context.Background(), &result, "eth_createAccessList", arg, "pending"); err != nil {
+		return nil, 0, errors.New(strings.Join([]string{"eth_createAccessList error", err.Error()}, " "))
+	}
+	if result.Error != "" {
+		return nil, 0, errors.New(strings.Join([]string{"eth_createAccessList call reverted", result.Error}, " "))
+	}
+	return result.AccessList, uint64(result.GasUsed), nil
+}
+
+// checkTxAccessList the type and access list in the tx file are what the operator reviews, they must match the signed bytes.
+// Files of earlier versions have no type.
+func checkTxAccessList(simpletx *Tx, tx *types.Transaction) error {
+	if simpletx.Type != 0 && simpletx.Type != tx.Type() {
+		return errors.New(strings.Join([]string{"tx file type does not match txhex of", simpletx.From}, " "))
+	}
+
+	accessList := tx.AccessList()
+	mismatch := errors.New(strings.Join([]string{"tx file access list does not match txhex of", simpletx.From}, " "))
+	if len(simpletx.AccessList) != len(accessList) {
+		return mismatch
+	}
+	for i, tuple := range accessList {
+		if simpletx.AccessList[i].Address != tuple.Address || len(simpletx.AccessList[i].StorageKeys) != len(tuple.StorageKeys) {
+			return mismatch
+		}
+		for j, key := range tuple.StorageKeys {
+			if simpletx.AccessList[i].StorageKeys[j] != key {
+				return mismatch
+			}
+		}
+	}
+	return nil
+}
@@ -4,27 +4,29 @@ import (
 	"bufio"
 	"crypto/ecdsa"
 	"crypto/sha256"
-	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"io"
 	"io/ioutil"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gocarina/gocsv"
 	log "github.com/sirupsen/logrus"
 	qrcode "github.com/skip2/go-qrcode"
 	bip39 "github.com/tyler-smith/go-bip39"
+	"github.com/tyler-smith/go-bip39/wordlists"
 
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcutil/hdkeychain"
 
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/pborman/uuid"
+	"github.com/google/uuid"
 )
 
 // RandomPwdJSON 随机密码
@@ -44,20 +46,52 @@ type MnemonicJSON struct {
 	Address  string `json:"address"`
 	Mnemonic string `json:"mnemonic"`
 	PATH     string `json:"path"`
+	Template string `json:"template,omitempty"`
+	Count    int    `json:"count,omitempty"`
+	// Indexes derivation indexes when they are not consecutive from 0
+	Indexes []uint32 `json:"indexes,omitempty"`
+	// Passphrase BIP39 passphrase is required to derive the seed
+	Passphrase bool `json:"passphrase,omitempty"`
+	// Bits entropy bits and Language word list of the mnemonic
+	Bits     int    `json:"bits,omitempty"`
+	Language string `json:"language,omitempty"`
+}
+
+// AccountMetaJSON 账户派生信息，Seed 为助记词二维码备份所属地址
+type AccountMetaJSON struct {
+	Address    string `json:"address"`
+	Seed       string `json:"seed"`
+	Index      uint32 `json:"index"`
+	PATH       string `json:"path"`
+	Passphrase bool   `json:"passphrase,omitempty"`
+	// Imported key not derived by this tool, no mnemonic backup
+	Imported bool `json:"imported,omitempty"`
+	// DualControl password halves are typed by two operators and never written to disk
+	DualControl bool `json:"dual_control,omitempty"`
+	// KDF keystore KDF profile, empty is standard
+	KDF string `json:"kdf,omitempty"`
 }
 
 type csvAddress struct {
 	Address string `csv:"address"`
 }
 
-func createAccount(accoutDir, timeDir string) (*string, error) {
+// derivedAccount 由助记词派生的账户
+type derivedAccount struct {
+	privateKey *ecdsa.PrivateKey
+	address    string
+	index      uint32
+	path       string
+}
+
+func createAccount(accoutDir, timeDir, template, passphrase string) (*string, error) {
 	// Generate a mnemonic for memorization or user-friendly seeds
-	mnemonic, err := mnemonicFun()
+	mnemonic, err := mnemonicFun(mnemonicBits)
 	if err != nil {
 		return nil, err
 	}
 
-	privateKey, path, err := hdWallet(*mnemonic)
+	privateKey, path, err := hdWallet(*mnemonic, passphrase, template, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -67,27 +101,173 @@ func createAccount(accoutDir, timeDir string) (*string, error) {
 	// get the address
 	address := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
 
-	// generate first rondom password
-	randomPwdFirst := RandStringBytesMaskImprSrc(50)
+	// createAccounts already runs on the worker pool, save the account on this goroutine
+	if err := saveSeedAccount(*mnemonic, &derivedAccount{privateKey, address, 0, *path}, template, passphrase, accoutDir, timeDir); err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// createAccounts create count accounts each with its own mnemonic on workers goroutines
+func createAccounts(accoutDir, timeDir, template, passphrase string, count, workers int) ([]string, error) {
+	addresses := make([]string, count)
+	err := runParallel("generating accounts", count, workers, func(index int) error {
+		address, err := createAccount(accoutDir, timeDir, template, passphrase)
+		if err != nil {
+			return err
+		}
+		addresses[index] = *address
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
+
+// createSeedAccounts derive count accounts at consecutive indexes from one mnemonic,
+// the mnemonic qrcode backup is saved once under the first address
+func createSeedAccounts(accoutDir, timeDir, template, passphrase string, count, workers int) ([]string, error) {
+	if count > 1 && !isIndexedDerivationPath(template) {
+		return nil, errors.New(strings.Join([]string{"derivation path", template, "has no index component x"}, " "))
+	}
+
+	mnemonic, err := mnemonicFun(mnemonicBits)
+	if err != nil {
+		return nil, err
+	}
+
+	masterKey, err := hdMasterKey(*mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		accounts  []*derivedAccount
+		addresses []string
+	)
+	for index := 0; index < count; index++ {
+		privateKey, path, err := deriveHDKey(masterKey, template, uint32(index))
+		if err != nil {
+			return nil, err
+		}
+
+		address := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+		accounts = append(accounts, &derivedAccount{privateKey, address, uint32(index), *path})
+		addresses = append(addresses, address)
+	}
+
+	if err := saveSeedAccounts(*mnemonic, accounts, template, passphrase, accoutDir, timeDir, workers); err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
+
+// saveSeedAccounts save accounts derived from one mnemonic on workers goroutines, the mnemonic qrcode backup is saved once under the first address
+func saveSeedAccounts(mnemonic string, accounts []*derivedAccount, template, passphrase, accoutDir, timeDir string, workers int) error {
+	seed := accounts[0]
+
+	// keystore scrypt is the slow part, save the accounts on the worker pool
+	err := runParallel("saving seed accounts", len(accounts), workers, func(i int) error {
+		saveAccount(accounts[i].privateKey, seedAccountMeta(seed, accounts[i], passphrase), accoutDir, timeDir)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return saveSeedBackup(mnemonic, accounts, template, passphrase, accoutDir, timeDir)
+}
+
+// saveSeedAccount save the only account of a mnemonic and its backup on the calling goroutine
+func saveSeedAccount(mnemonic string, account *derivedAccount, template, passphrase, accoutDir, timeDir string) error {
+	saveAccount(account.privateKey, seedAccountMeta(account, account, passphrase), accoutDir, timeDir)
+	return saveSeedBackup(mnemonic, []*derivedAccount{account}, template, passphrase, accoutDir, timeDir)
+}
 
-	// generate second rondom password
-	randomPwdSecond := RandStringBytesMaskImprSrc(60)
+func seedAccountMeta(seed, account *derivedAccount, passphrase string) *AccountMetaJSON {
+	return &AccountMetaJSON{
+		Seed:        seed.address,
+		Index:       account.index,
+		PATH:        account.path,
+		Passphrase:  passphrase != "",
+		DualControl: dualControl,
+	}
+}
 
-	// save mnemonic qrcode
-	saveAESEncryptMnemonicQrcode(address, *mnemonic, *path, accoutDir, timeDir)
+// saveSeedBackup save the mnemonic qrcode backup of the seed, and its xpub if exportXpub is set
+func saveSeedBackup(mnemonic string, accounts []*derivedAccount, template, passphrase, accoutDir, timeDir string) error {
+	seed := accounts[0]
+	consecutive := true
+	indexes := []uint32{}
+	for i, account := range accounts {
+		if account.index != uint32(i) {
+			consecutive = false
+		}
+		indexes = append(indexes, account.index)
+	}
 
-	// save keystore to configure path
-	saveKeystore(privateKey, randomPwdFirst, randomPwdSecond, accoutDir, timeDir)
-	// save random pwd with address to configure path
-	saveRandomPwd(address, randomPwdFirst, accoutDir, "random_pwd_first", timeDir)
-	saveRandomPwd(address, randomPwdSecond, accoutDir, "random_pwd_second", timeDir)
+	m := &MnemonicJSON{
+		Address:    seed.address,
+		Mnemonic:   mnemonic,
+		PATH:       seed.path,
+		Template:   template,
+		Count:      len(accounts),
+		Passphrase: passphrase != "",
+		Bits:       mnemonicBits,
+		Language:   mnemonicLanguage,
+	}
+	if !consecutive {
+		m.Indexes = indexes
+	}
+	// save mnemonic qrcode, one backup for the whole seed
+	saveAESEncryptMnemonicQrcode(m, accoutDir, timeDir)
+
+	if exportXpub {
+		if err := saveXpub(mnemonic, passphrase, template, seed.address, len(accounts), accoutDir, timeDir); err != nil {
+			return errors.New(strings.Join([]string{"export xpub error", err.Error()}, " "))
+		}
+	}
+	return nil
+}
+
+func saveAccount(privateKey *ecdsa.PrivateKey, meta *AccountMetaJSON, accoutDir, timeDir string) {
+	address := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+
+	if meta.DualControl {
+		operatorPwdFirst, operatorPwdSecond, err := promptOperatorPwds(timeDir)
+		if err != nil {
+			log.Fatalln(err.Error())
+		}
+		saveKeystore(privateKey, operatorPwdFirst, operatorPwdSecond, accoutDir, timeDir)
+	} else {
+		// generate first rondom password
+		randomPwdFirst, err := secret.generate(firstPwdLength)
+		if err != nil {
+			log.Fatalln("generate random password error", err.Error())
+		}
+
+		// generate second rondom password
+		randomPwdSecond, err := secret.generate(secondPwdLength)
+		if err != nil {
+			log.Fatalln("generate random password error", err.Error())
+		}
+
+		// save keystore to configure path
+		saveKeystore(privateKey, randomPwdFirst, randomPwdSecond, accoutDir, timeDir)
+		// save random pwd with address to configure path
+		saveRandomPwd(address, randomPwdFirst, "random_pwd_first", timeDir)
+		saveRandomPwd(address, randomPwdSecond, "random_pwd_second", timeDir)
+	}
+	// save derivation index next to keystore
+	meta.Address = address
+	meta.KDF = keystoreKDF.name
+	saveAccountMeta(meta, accoutDir, timeDir)
 
 	log.WithFields(log.Fields{
 		"Generate Ethereum account": address,
+		"Derivation path":           meta.PATH,
 		"Time:":                     time.Now().Format("Mon Jan _2 15:04:05 2006"),
 	}).Info("")
-
-	return &address, nil
 }
 
 func accountAuth(randomPwdFirst, randomPwdSecond string) string {
@@ -98,9 +278,37 @@ func accountAuth(randomPwdFirst, randomPwdSecond string) string {
 	return auth
 }
 
-func mnemonicFun() (*string, error) {
+// mnemonicWordLists go-bip39 自带的助记词词库
+var mnemonicWordLists = map[string][]string{
+	"english":             wordlists.English,
+	"chinese_simplified":  wordlists.ChineseSimplified,
+	"chinese_traditional": wordlists.ChineseTraditional,
+	"japanese":            wordlists.Japanese,
+	"korean":              wordlists.Korean,
+	"french":              wordlists.French,
+	"italian":             wordlists.Italian,
+	"spanish":             wordlists.Spanish,
+}
+
+func setMnemonicLanguage(language string) error {
+	wordList, ok := mnemonicWordLists[strings.ToLower(language)]
+	if !ok {
+		return errors.New(strings.Join([]string{"unsupported mnemonic language:", language}, " "))
+	}
+	bip39.SetWordList(wordList)
+	return nil
+}
+
+func validateMnemonicBits(bits int) error {
+	if bits < 128 || bits > 256 || bits%32 != 0 {
+		return errors.New("mnemonic entropy bits must be one of 128, 160, 192, 224, 256")
+	}
+	return nil
+}
+
+func mnemonicFun(bits int) (*string, error) {
 	// Generate a mnemonic for memorization or user-friendly seeds
-	entropy, err := bip39.NewEntropy(128)
+	entropy, err := bip39.NewEntropy(bits)
 	if err != nil {
 		return nil, err
 	}
@@ -113,56 +321,44 @@ func mnemonicFun() (*string, error) {
 	return &mnemonic, nil
 }
 
-func hdWallet(mnemonic string) (*ecdsa.PrivateKey, *string, error) {
-	// Generate a Bip32 HD wallet for the mnemonic and a user supplied password
-	seed := bip39.NewSeed(mnemonic, "")
-
-	// Generate a new master node using the seed.
-	masterKey, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	// This gives the path: m/44H
-	acc44H, err := masterKey.Child(hdkeychain.HardenedKeyStart + 44)
+func hdWallet(mnemonic, passphrase, template string, index uint32) (*ecdsa.PrivateKey, *string, error) {
+	masterKey, err := hdMasterKey(mnemonic, passphrase)
 	if err != nil {
 		return nil, nil, err
 	}
+	return deriveHDKey(masterKey, template, index)
+}
 
-	// This gives the path: m/44H/60H
-	acc44H60H, err := acc44H.Child(hdkeychain.HardenedKeyStart + 60)
-	if err != nil {
-		return nil, nil, err
-	}
+func hdMasterKey(mnemonic, passphrase string) (*hdkeychain.ExtendedKey, error) {
+	// Generate a Bip32 HD wallet for the mnemonic and a user supplied password
+	seed := bip39.NewSeed(mnemonic, passphrase)
 
-	// This gives the path: m/44H/60H/0H
-	acc44H60H0H, err := acc44H60H.Child(hdkeychain.HardenedKeyStart + 0)
-	if err != nil {
-		return nil, nil, err
-	}
+	// Generate a new master node using the seed.
+	return hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+}
 
-	// This gives the path: m/44H/60H/0H/0
-	acc44H60H0H0, err := acc44H60H0H.Child(0)
+func deriveHDKey(masterKey *hdkeychain.ExtendedKey, template string, index uint32) (*ecdsa.PrivateKey, *string, error) {
+	// This gives the path like: m/44H/60H/0H/0/index
+	children, path, err := parseDerivationPath(template, index)
 	if err != nil {
 		return nil, nil, err
 	}
-
-	// This gives the path: m/44H/60H/0H/0/0
-	acc44H60H0H00, err := acc44H60H0H0.Child(0)
-	if err != nil {
-		return nil, nil, err
+	key := masterKey
+	for _, child := range children {
+		key, err = key.Child(child)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
-	btcecPrivKey, err := acc44H60H0H00.ECPrivKey()
+	btcecPrivKey, err := key.ECPrivKey()
 	if err != nil {
 		return nil, nil, err
 	}
 
 	privateKey := btcecPrivKey.ToECDSA()
 
-	path := "m/44H/60H/0H/0/0"
-
-	return privateKey, &path, nil
+	return privateKey, path, nil
 }
 
 func saveFixedPwd(address, fixedPwd, dir string) {
@@ -185,7 +381,7 @@ func saveFixedPwd(address, fixedPwd, dir string) {
 	}
 }
 
-func saveRandomPwd(address, randomPwd, dir, rdname, timeDir string) {
+func saveRandomPwd(address, randomPwd, rdname, timeDir string) {
 	randomPwdJSON := RandomPwdJSON{
 		address,
 		randomPwd,
@@ -195,7 +391,7 @@ func saveRandomPwd(address, randomPwd, dir, rdname, timeDir string) {
 		log.Fatalf(err.Error())
 	}
 	hexRandomPwdJSON = append(hexRandomPwdJSON, '\n')
-	randomPwdPath, err := mkdirBySlice([]string{dir, rdname, timeDir})
+	randomPwdPath, err := mkdirBySlice([]string{pwdRoot(rdname), rdname, timeDir})
 	if err != nil {
 		log.Fatalln("Could not create directory", err.Error())
 	}
@@ -205,22 +401,109 @@ func saveRandomPwd(address, randomPwd, dir, rdname, timeDir string) {
 	}
 }
 
-func readPwd(address, pwdType, timeDir string) (*string, error) {
-	var (
-		PwdFile string
-	)
+func saveAccountMeta(meta *AccountMetaJSON, dir, timeDir string) {
+	bMetaJSON, err := json.Marshal(meta)
+	if err != nil {
+		log.Fatalf(err.Error())
+	}
+	bMetaJSON = append(bMetaJSON, '\n')
+	metaPath, err := mkdirBySlice([]string{dir, "keystore", timeDir})
+	if err != nil {
+		log.Fatalln("Could not create directory", err.Error())
+	}
+	metaFile := strings.Join([]string{*metaPath, "meta.json"}, "/")
+	if err = appenFile(metaFile, bMetaJSON, 0600); err != nil {
+		log.Fatalln("Failed to write meta to", err.Error())
+	}
+}
+
+func readAccountMeta(address, timeDir string) (*AccountMetaJSON, error) {
+	metaFile := strings.Join([]string{HomeDir(), "account", "keystore", timeDir, "meta.json"}, "/")
+	jsonFile, err := os.Open(metaFile)
+	if err != nil {
+		return nil, err
+	}
+	defer jsonFile.Close()
+
+	reader := bufio.NewReader(jsonFile)
+	for {
+		line, _, err := reader.ReadLine()
+		if err == io.EOF {
+			break
+		}
+
+		var meta AccountMetaJSON
+		json.Unmarshal(line, &meta)
+		if strings.EqualFold(meta.Address, address) {
+			return &meta, nil
+		}
+	}
+	return nil, errors.New(strings.Join([]string{address, "not found in meta.json"}, " "))
+}
+
+// pwdRoot root directory of the password half, configure random_pwd_first_root and random_pwd_second_root
+// to keep the halves on separate media, default ~/account
+func pwdRoot(pwdType string) string {
+	var root string
 	switch pwdType {
 	case "random_pwd_first":
-		dir := strings.Join([]string{HomeDir(), "account", "random_pwd_first", timeDir}, "/")
-		PwdFile = strings.Join([]string{dir, "randompwd.json"}, "/")
+		root = config.RandomPwdFirstRoot
 	case "random_pwd_second":
-		dir := strings.Join([]string{HomeDir(), "account", "random_pwd_second", timeDir}, "/")
-		PwdFile = strings.Join([]string{dir, "randompwd.json"}, "/")
+		root = config.RandomPwdSecondRoot
+	}
+	if root == "" {
+		return strings.Join([]string{HomeDir(), "account"}, "/")
+	}
+	return root
+}
+
+// checkPwdMedia make sure the configured password roots are mounted, ask operator to mount them if prompt is set
+func checkPwdMedia(prompt bool) error {
+	for _, pwdType := range []string{"random_pwd_first", "random_pwd_second"} {
+		root := pwdRoot(pwdType)
+		if prompt && root != strings.Join([]string{HomeDir(), "account"}, "/") {
+			promptMedium(pwdType, root)
+		}
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			return errors.New(strings.Join([]string{pwdType, "medium not mounted at", root}, " "))
+		}
+	}
+	return nil
+}
+
+func pwdFilePath(pwdType, timeDir string) (*string, error) {
+	switch pwdType {
+	case "random_pwd_first", "random_pwd_second":
+		pwdFile := strings.Join([]string{pwdRoot(pwdType), pwdType, timeDir, "randompwd.json"}, "/")
+		return &pwdFile, nil
 	default:
 		return nil, errors.New("pwdType error")
 	}
+}
+
+func readPwd(address, pwdType, timeDir string) (*string, error) {
+	pwds, err := readPwdEntries(pwdType, timeDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var pwd = new(string)
+	for _, randompwd := range pwds {
+		if randompwd.Address == address {
+			pwd = &(randompwd.Randompwd)
+			return pwd, nil
+		}
+	}
+	return pwd, nil
+}
+
+func readPwdEntries(pwdType, timeDir string) ([]*RandomPwdJSON, error) {
+	PwdFile, err := pwdFilePath(pwdType, timeDir)
+	if err != nil {
+		return nil, err
+	}
 
-	jsonFile, err := os.Open(PwdFile)
+	jsonFile, err := os.Open(*PwdFile)
 	if err != nil {
 		return nil, err
 	}
@@ -228,7 +511,7 @@ func readPwd(address, pwdType, timeDir string) (*string, error) {
 
 	reader := bufio.NewReader(jsonFile)
 
-	var pwd = new(string)
+	pwds := []*RandomPwdJSON{}
 	for {
 		line, _, err := reader.ReadLine()
 		if err == io.EOF {
@@ -236,41 +519,58 @@ func readPwd(address, pwdType, timeDir string) (*string, error) {
 		}
 
 		var randompwd RandomPwdJSON
-		json.Unmarshal(line, &randompwd)
-		if randompwd.Address == address {
-			pwd = &(randompwd.Randompwd)
-			return pwd, nil
+		if err := json.Unmarshal(line, &randompwd); err != nil {
+			continue
 		}
+		pwds = append(pwds, &randompwd)
 	}
-	return pwd, nil
+	return pwds, nil
 }
 
-func saveAESEncryptMnemonicQrcode(address, mnemonic, path, dir, timeStr string) {
+// writePwdEntries replace the whole randompwd.json atomically
+func writePwdEntries(pwdType, timeDir string, pwds []*RandomPwdJSON) error {
+	PwdFile, err := pwdFilePath(pwdType, timeDir)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	for _, randompwd := range pwds {
+		hexRandomPwdJSON, err := json.Marshal(randompwd)
+		if err != nil {
+			return err
+		}
+		data = append(data, hexRandomPwdJSON...)
+		data = append(data, '\n')
+	}
+	return writeFileAtomic(*PwdFile, data, 0600)
+}
+
+func saveAESEncryptMnemonicQrcode(m *MnemonicJSON, dir, timeStr string) {
 	// AES encrypt key should be 16 bytes (AES-128) or 32 (AES-256).
-	randomPwd := RandStringBytesMaskImprSrc(32)
-	m := &MnemonicJSON{
-		address,
-		mnemonic,
-		path,
+	randomPwd, err := secret.generate(32)
+	if err != nil {
+		log.Fatalln("generate AES key error", err.Error())
 	}
 	bMnemonicJSON, _ := json.Marshal(m)
 
-	mNemonicCrypted, err := AesEncrypt(bMnemonicJSON, []byte(randomPwd))
+	payload, err := encryptMnemonicPayload(bMnemonicJSON, randomPwd)
 	if err != nil {
 		log.Fatalln("crypted mnemonic error", err.Error())
 	}
 
-	// save ASE 256 encode mnemonic and randomPwd(AesDecrypt key) qrcode
-	saveAES256EncodeMnemonicQrcode(mNemonicCrypted, randomPwd, address, dir, timeStr, 512)
-}
+	// save AES-GCM encrypted mnemonic and randomPwd(AES key derivation password) qrcode
+	keyTexts := saveAES256EncodeMnemonicQrcode(payload, randomPwd, m.Address, dir, timeStr, 512)
 
-func saveAES256EncodeMnemonicQrcode(mNemonicCrypted []byte, key, address, dir, timeDir string, size int) {
-	h := sha256.New()
-	h.Write(mNemonicCrypted)
-	mnemonicSha := base64.URLEncoding.EncodeToString(h.Sum(nil))
-	mnemonicScryptedStr := base64.StdEncoding.EncodeToString(mNemonicCrypted)
-	mnemonicSha256AndAESResult := strings.Join([]string{mnemonicScryptedStr, mnemonicSha}, "")
+	if paperFormat != "" {
+		if err := savePaperWallet(m, payload, keyTexts, dir, timeStr); err != nil {
+			log.Fatalln("save paper wallet error", err.Error())
+		}
+	}
+}
 
+// saveAES256EncodeMnemonicQrcode save the mnemonic qrcode and key qrcode, return the text of key qrcode or its shares
+func saveAES256EncodeMnemonicQrcode(payload, key, address, dir, timeDir string, size int) []string {
 	mnemonicPNGPath, err := mkdirBySlice([]string{dir, "mnemonic_qrcode", timeDir, address})
 	if err != nil {
 		log.Fatalln("Could not create directory", err.Error())
@@ -278,12 +578,18 @@ func saveAES256EncodeMnemonicQrcode(mNemonicCrypted []byte, key, address, dir, t
 
 	mnemonicAesDecryptPNGName := strings.Join([]string{address, "aesdecrypt_mnemonic.png"}, "_")
 	mnemonicAesDecryptPNGFile := strings.Join([]string{*mnemonicPNGPath, mnemonicAesDecryptPNGName}, "/")
-	if err := qrcode.WriteFile(mnemonicSha256AndAESResult, qrcode.Highest, size, mnemonicAesDecryptPNGFile); err != nil {
+	if err := qrcode.WriteFile(payload, qrcode.Highest, size, mnemonicAesDecryptPNGFile); err != nil {
 		log.Fatalln("encode encrypt qrcode error", err.Error())
 	}
 
 	wm(mnemonicAesDecryptPNGFile, address, "aesdecrypt_mnemonic")
 
+	os.Remove(mnemonicAesDecryptPNGFile)
+
+	if keyShares > 0 {
+		return saveAESKeySharesQrcode(key, address, *mnemonicPNGPath, size)
+	}
+
 	AesDecryptKeyPNGName := strings.Join([]string{address, "aesdecrypt_key.png"}, "_")
 	AesDecryptKeyPNGFile := strings.Join([]string{*mnemonicPNGPath, AesDecryptKeyPNGName}, "/")
 	if err := qrcode.WriteFile(key, qrcode.Medium, size, AesDecryptKeyPNGFile); err != nil {
@@ -292,15 +598,41 @@ func saveAES256EncodeMnemonicQrcode(mNemonicCrypted []byte, key, address, dir, t
 
 	wm(AesDecryptKeyPNGFile, address, "aesdecrypt_key")
 
-	os.Remove(mnemonicAesDecryptPNGFile)
 	os.Remove(AesDecryptKeyPNGFile)
+	return []string{key}
+}
+
+// saveAESKeySharesQrcode split the AES decrypt key into keyShares shares with threshold keyThreshold,
+// each share is saved as its own marked qrcode
+func saveAESKeySharesQrcode(key, address, mnemonicPNGPath string, size int) []string {
+	shares, err := shamirSplit([]byte(key), keyShares, keyThreshold)
+	if err != nil {
+		log.Fatalln("split key error", err.Error())
+	}
+
+	shareTexts := []string{}
+	for i, share := range shares {
+		shareNo := strconv.Itoa(i + 1)
+		sharePNGName := strings.Join([]string{address, "aesdecrypt_key_share", shareNo + ".png"}, "_")
+		sharePNGFile := strings.Join([]string{mnemonicPNGPath, sharePNGName}, "/")
+		shareText := encodeShare(share, keyShares, keyThreshold)
+		if err := qrcode.WriteFile(shareText, qrcode.Medium, size, sharePNGFile); err != nil {
+			log.Fatalln("encode key share qrcode error", err.Error())
+		}
+		shareTexts = append(shareTexts, shareText)
+
+		qrcodeType := strings.Join([]string{"aesdecrypt_key_share", shareNo, "of", strconv.Itoa(keyShares), "threshold", strconv.Itoa(keyThreshold)}, "_")
+		wm(sharePNGFile, address, qrcodeType)
+		os.Remove(sharePNGFile)
+	}
+	return shareTexts
 }
 
 func saveMnemonic(address, mnemonic, path, dir string) {
 	m := &MnemonicJSON{
-		address,
-		mnemonic,
-		path,
+		Address:  address,
+		Mnemonic: mnemonic,
+		PATH:     path,
 	}
 
 	hexMnemonicJSON, _ := json.Marshal(m)
@@ -317,13 +649,17 @@ func saveMnemonic(address, mnemonic, path, dir string) {
 }
 
 func saveKeystore(key *ecdsa.PrivateKey, randomPwdFirst, randomPwdSecond, dir, timeDir string) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		log.Fatalf(err.Error())
+	}
 	ks := &keystore.Key{
-		Id:         uuid.NewRandom(),
+		Id:         id,
 		Address:    crypto.PubkeyToAddress(key.PublicKey),
 		PrivateKey: key,
 	}
 	auth := accountAuth(randomPwdFirst, randomPwdSecond)
-	keyjson, err := keystore.EncryptKey(ks, auth, keystore.StandardScryptN, keystore.StandardScryptP)
+	keyjson, err := keystoreKDF.encryptKey(ks, auth)
 	if err != nil {
 		log.Fatalf(err.Error())
 	}
@@ -352,31 +688,64 @@ func decodeKS2Key(addressHex string) (*keystore.Key, error) {
 	if err != nil {
 		return nil, err
 	}
-	ksPath := strings.Join([]string{HomeDir(), "account", "keystore", *timeDir}, "/")
+	return decodeKS2KeyInDir(addressHex, *timeDir)
+}
+
+func decodeKS2KeyInDir(addressHex, timeDir string) (*keystore.Key, error) {
+	ksPath := strings.Join([]string{HomeDir(), "account", "keystore", timeDir}, "/")
 	keyjson, err := readKeyStore(addressHex, ksPath)
 	if err != nil {
 		return nil, errors.New(strings.Join([]string{"read keystore error", err.Error()}, " "))
 	}
 
-	randomPwdFirst, err := readPwd(addressHex, "random_pwd_first", *timeDir)
+	var auth string
+	if meta, err := readAccountMeta(addressHex, timeDir); err == nil && meta.DualControl {
+		operatorPwdFirst, operatorPwdSecond, err := promptOperatorPwds(timeDir)
+		if err != nil {
+			return nil, errors.New(strings.Join([]string{"read operator password error", err.Error()}, " "))
+		}
+		auth = accountAuth(operatorPwdFirst, operatorPwdSecond)
+	} else {
+		randomPwdFirst, err := readPwd(addressHex, "random_pwd_first", timeDir)
+		if err != nil {
+			return nil, errors.New(strings.Join([]string{"read random_pwd_first error", err.Error()}, " "))
+		}
+
+		randomPwdSecond, err := readPwd(addressHex, "random_pwd_second", timeDir)
+		if err != nil {
+			return nil, errors.New(strings.Join([]string{"read random_pwd_second error", err.Error()}, " "))
+		}
+		auth = accountAuth(*randomPwdFirst, *randomPwdSecond)
+	}
+	key, err := keystore.DecryptKey(keyjson, auth)
 	if err != nil {
-		return nil, errors.New(strings.Join([]string{"read random_pwd_first error", err.Error()}, " "))
+		return nil, err
 	}
+	return key, nil
+}
 
-	randomPwdSecond, err := readPwd(addressHex, "random_pwd_second", *timeDir)
+func readCSVAddresses(path string) ([]*csvAddress, error) {
+	addressPath := strings.Join([]string{path, "eth_address.csv"}, "/")
+	addressFile, err := os.Open(addressPath)
 	if err != nil {
-		return nil, errors.New(strings.Join([]string{"read random_pwd_second error", err.Error()}, " "))
+		return nil, err
 	}
+	defer addressFile.Close()
 
-	auth := accountAuth(*randomPwdFirst, *randomPwdSecond)
-	key, err := keystore.DecryptKey(keyjson, auth)
-	if err != nil {
+	addresses := []*csvAddress{}
+	if err := gocsv.UnmarshalFile(addressFile, &addresses); err != nil {
 		return nil, err
 	}
-	return key, nil
+	return addresses, nil
 }
 
+// csvMu serialize eth_address.csv writers, header is only written to an empty file
+var csvMu sync.Mutex
+
 func export2CSV(addresses []*csvAddress, path string) {
+	csvMu.Lock()
+	defer csvMu.Unlock()
+
 	addressPath := strings.Join([]string{path, "eth_address.csv"}, "/")
 	addressFile, err := os.OpenFile(addressPath, os.O_RDWR|os.O_CREATE|os.O_APPEND, os.ModePerm)
 	if err != nil {
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// chainProfile EVM 链配置
+type chainProfile struct {
+	name     string
+	chainID  *big.Int
+	coinType uint32
+	// node endpoints, only the profile named by net_mode falls back to the top level eth_rpc, geth_rpc,
+	// parity_rpc and etherscan url, other chains must set their own
+	ethRPC       string
+	gethRPC      string
+	parityRPC    string
+	etherscanURL string
+	explorer     string
+	decimals     int32
+	// mainnet holds real value, sign refuses test kdf profile keys
+	mainnet bool
+}
+
+// chainProfiles built-in chain profiles, chains in configure override or add to them
+var chainProfiles = map[string]*chainProfile{
+	"mainnet":    {name: "mainnet", chainID: big.NewInt(1), coinType: 60, explorer: "https://etherscan.io", decimals: 18, mainnet: true},
+	"privatenet": {name: "privatenet", chainID: big.NewInt(1337), coinType: 60, decimals: 18},
+	"sepolia":    {name: "sepolia", chainID: big.NewInt(11155111), coinType: 60, etherscanURL: "https://api-sepolia.etherscan.io/api", explorer: "https://sepolia.etherscan.io", decimals: 18},
+	"holesky":    {name: "holesky", chainID: big.NewInt(17000), coinType: 60, etherscanURL: "https://api-holesky.etherscan.io/api", explorer: "https://holesky.etherscan.io", decimals: 18},
+	"classic":    {name: "classic", chainID: big.NewInt(61), coinType: 61, explorer: "https://blockscout.com/etc/mainnet", decimals: 18, mainnet: true},
+	"bsc":        {name: "bsc", chainID: big.NewInt(56), coinType: 60, etherscanURL: "https://api.bscscan.com/api", explorer: "https://bscscan.com", decimals: 18, mainnet: true},
+}
+
+// chain profile selected by --chain, chain or net_mode in configure
+var chain *chainProfile
+
+// initChainProfile select the chain profile, its endpoints take precedence over the top level ones
+func initChainProfile(name string) error {
+	if name == "" {
+		name = config.Chain
+	}
+	if name == "" {
+		// net_mode privatenet and mainnet are built-in profiles
+		name = config.NetMode
+	}
+	if name == "" {
+		return errors.New("you must set chain or net_mode in configure")
+	}
+
+	profile, ok := chainProfiles[strings.ToLower(name)]
+	if !ok {
+		return errors.New(strings.Join([]string{"unknown chain profile:", name}, " "))
+	}
+	if profile.chainID == nil || profile.chainID.Sign() <= 0 {
+		return errors.New(strings.Join([]string{"chain profile", name, "requires chain_id"}, " "))
+	}
+
+	// the top level endpoints belong to the net_mode chain, never send another chain's txs to them
+	inherit := strings.EqualFold(profile.name, config.NetMode)
+	if profile.ethRPC != "" || !inherit {
+		config.EthRPC = profile.ethRPC
+	}
+	if profile.gethRPC != "" || !inherit {
+		config.GethRPC = profile.gethRPC
+	}
+	if profile.parityRPC != "" || !inherit {
+		config.ParityRPC = profile.parityRPC
+	}
+	if profile.etherscanURL != "" || !inherit {
+		etherscan.URL = profile.etherscanURL
+	}
+	chain = profile
+	return nil
+}
+
+// requireChainEndpoint fail when the chain profile has no endpoint of the node, eth is the eth_rpc used by send
+func requireChainEndpoint(node string) error {
+	var key, endpoint string
+	switch node {
+	case "geth":
+		key, endpoint = "geth_rpc", config.GethRPC
+	case "parity":
+		key, endpoint = "parity_rpc", config.ParityRPC
+	case "etherscan":
+		key, endpoint = "etherscan_url", etherscan.URL
+	default:
+		key, endpoint = "eth_rpc", config.EthRPC
+	}
+	if endpoint == "" {
+		return errors.New(strings.Join([]string{"chain profile", chain.name, "has no", key, "set it under chains in configure"}, " "))
+	}
+	return nil
+}
+
+// mergeChainProfile apply configure settings on top of the built-in profile of the same name
+func mergeChainProfile(name string, v *viper.Viper) *chainProfile {
+	profile := &chainProfile{name: name, decimals: 18}
+	if builtIn, ok := chainProfiles[name]; ok {
+		copied := *builtIn
+		profile = &copied
+	}
+
+	if v.IsSet("chain_id") {
+		profile.chainID = big.NewInt(v.GetInt64("chain_id"))
+	}
+	if v.IsSet("coin_type") {
+		profile.coinType = uint32(v.GetInt64("coin_type"))
+	}
+	if v.IsSet("eth_rpc") {
+		profile.ethRPC = v.GetString("eth_rpc")
+	}
+	if v.IsSet("geth_rpc") {
+		profile.gethRPC = v.GetString("geth_rpc")
+	}
+	if v.IsSet("parity_rpc") {
+		profile.parityRPC = v.GetString("parity_rpc")
+	}
+	if v.IsSet("etherscan_url") {
+		profile.etherscanURL = v.GetString("etherscan_url")
+	}
+	if v.IsSet("explorer") {
+		profile.explorer = v.GetString("explorer")
+	}
+	if v.IsSet("decimals") {
+		profile.decimals = int32(v.GetInt("decimals"))
+	}
+	if v.IsSet("mainnet") {
+		profile.mainnet = v.GetBool("mainnet")
+	}
+	return profile
+}
+
+// explorerTxURL link of the transaction on the chain explorer
+func explorerTxURL(hash string) string {
+	if chain == nil || chain.explorer == "" {
+		return hash
+	}
+	return strings.Join([]string{strings.TrimRight(chain.explorer, "/"), "tx", hash}, "/")
+}
+
+// checkCoinType refuse a BIP44 path whose coin type is not the one of the chain profile, other paths are not checked
+func checkCoinType(path string, coinType uint32) error {
+	components := strings.Split(path, "/")
+	if len(components) < 3 || strings.TrimRight(components[1], "Hh'") != "44" {
+		return nil
+	}
+	if strings.TrimRight(components[2], "Hh'") != strconv.FormatUint(uint64(coinType), 10) {
+		return errors.New(strings.Join([]string{"derivation path", path, "does not use coin type", strconv.FormatUint(uint64(coinType), 10), "of chain", chain.name}, " "))
+	}
+	return nil
+}
+
+// withCoinType replace the BIP44 coin type of the derivation template
+func withCoinType(template string, coinType uint32) string {
+	components := strings.Split(template, "/")
+	if len(components) < 3 || strings.TrimRight(components[1], "Hh'") != "44" {
+		return template
+	}
+	components[2] = strings.Join([]string{strconv.FormatUint(uint64(coinType), 10), "H"}, "")
+	return strings.Join(components, "/")
+}
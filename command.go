@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
@@ -12,8 +13,54 @@ import (
 )
 
 var (
-	number int
-	node   string
+	number           int
+	node             string
+	oneSeed          bool
+	derivationPath   string
+	derivationPreset string
+	mnemonicQrcode   string
+	keyQrcode        string
+	mnemonicData     string
+	keyData          string
+	saveKeystoreFlag bool
+	keyShares        int
+	keyThreshold     int
+	shareQrcodes     []string
+	shareData        []string
+	withPassphrase   bool
+	mnemonicBits     int
+	mnemonicLanguage string
+	pwdAlphabet      string
+	firstPwdLength   int
+	secondPwdLength  int
+	importKeystores  []string
+	importKeys       []string
+	exportAddress    string
+	exportStrength   string
+	exportOutDir     string
+	verifyFormat     string
+	verifyBatch      string
+	rotateBatch      string
+	rotateAddresses  []string
+	dualControl      bool
+	vanityPrefix     string
+	vanitySuffix     string
+	workers          int
+	paperFormat      string
+	paperSplit       bool
+	exportXpub       bool
+	xpubFile         string
+	xpubKey          string
+	xpubPath         string
+	deriveFrom       uint32
+	deriveCount      uint32
+	kdfProfileName   string
+	chainName        string
+	feeMode          string
+	withAccessList   bool
+	sweepTokens      []string
+	sweepDust        bool
+	fundingWait      time.Duration
 )
 
 // EtherScan 配置
@@ -35,6 +82,33 @@ type configure struct {
 	GethRPC      string
 	ParityRPC    string
 	EtherscanRPC string
+	// BIP32 derivation path template and wallet compatibility preset
+	DerivationPath   string
+	DerivationPreset string
+	// mnemonic entropy bits and word list language
+	MnemonicBits     int
+	MnemonicLanguage string
+	// root directories of the two random password halves
+	RandomPwdFirstRoot  string
+	RandomPwdSecondRoot string
+	// random password alphabet and length
+	SecretAlphabet  string
+	FirstPwdLength  int
+	SecondPwdLength int
+	// keystore KDF profile and custom profiles
+	KDFProfile  string
+	KDFProfiles map[string]*kdfProfile
+	// chain profile name, profiles are in chainProfiles
+	Chain string
+	// Fee transaction fee mode and EIP-1559 fee strategies
+	Fee *feeStrategy
+	// ERC-20 token registry by lower case name
+	Tokens map[string]*tokenConfig
+	// GasStation address tops up the gas of token sweeps, at most MaxTopUp per deposit address
+	// and MaxTopUpTotal per construct, sign and send run
+	GasStation    string
+	MaxTopUp      float64
+	MaxTopUpTotal float64
 }
 
 // rootCmd represents the base command when called without any subcommands
@@ -48,22 +122,143 @@ var genAccountCmd = &cobra.Command{
 	Use:   "genaccount",
 	Short: "Generate ethereum account",
 	Run: func(cmd *cobra.Command, args []string) {
+		template := initGenAccountOptions(cmd)
+
+		var passphrase string
+		if withPassphrase {
+			fmt.Println("Enter BIP39 passphrase, it is required to recover the accounts")
+			pwd, err := promptPwd()
+			if err != nil {
+				log.Fatalln(err.Error())
+			}
+			passphrase = *pwd
+		}
+
 		timeFormat := time.Now().Format("2006-01-02_15-04-05")
 		timeDir := strings.Join([]string{"version_1", timeFormat}, "_")
 
+		if dualControl {
+			fmt.Println("Dual control: each operator types a password half, it is never written to disk and is required to sign")
+			if _, _, err := promptOperatorPwds(timeDir); err != nil {
+				log.Fatalln(err.Error())
+			}
+		}
+
 		accountDir, err := mkdirBySlice([]string{HomeDir(), "account"})
 		if err != nil {
 			log.Fatalln("Fail to create account directory")
 		}
 		addresses := []*csvAddress{}
-		for index := 0; index < number; index++ {
-			address, err := createAccount(*accountDir, timeDir)
+		if vanityPrefix != "" || vanitySuffix != "" {
+			pattern, err := newVanityPattern(vanityPrefix, vanitySuffix)
+			if err != nil {
+				log.Fatalln(err.Error())
+			}
+			var vanityAddresses []string
+			if oneSeed {
+				vanityAddresses, err = createVanitySeedAccounts(*accountDir, timeDir, *template, passphrase, pattern, number, workers)
+			} else {
+				vanityAddresses, err = createVanityAccounts(*accountDir, timeDir, *template, passphrase, pattern, number, workers)
+			}
 			if err != nil {
 				log.Fatalln(err.Error())
 			}
-			addresses = append(addresses, &csvAddress{Address: *address})
+			for _, address := range vanityAddresses {
+				addresses = append(addresses, &csvAddress{Address: address})
+			}
+		} else if oneSeed {
+			seedAddresses, err := createSeedAccounts(*accountDir, timeDir, *template, passphrase, number, workers)
+			if err != nil {
+				log.Fatalln(err.Error())
+			}
+			for _, address := range seedAddresses {
+				addresses = append(addresses, &csvAddress{Address: address})
+			}
+		} else {
+			newAddresses, err := createAccounts(*accountDir, timeDir, *template, passphrase, number, workers)
+			if err != nil {
+				log.Fatalln(err.Error())
+			}
+			for _, address := range newAddresses {
+				addresses = append(addresses, &csvAddress{Address: address})
+			}
 		}
 		export2CSV(addresses, *accountDir)
+		if err := writeBatchManifest(timeDir); err != nil {
+			log.Fatalln("write manifest error", err.Error())
+		}
+	},
+}
+
+var recoverCmd = &cobra.Command{
+	Use:   "recover",
+	Short: "Recover ethereum account from mnemonic qrcode backup",
+	Run: func(cmd *cobra.Command, args []string) {
+		initConfigIfExists()
+		if saveKeystoreFlag {
+			if err := checkPwdMedia(true); err != nil {
+				log.Fatalln(err.Error())
+			}
+			if err := initKDFProfile(kdfProfileName); err != nil {
+				log.Fatalln(err.Error())
+			}
+		}
+		recoverAccountCmd(mnemonicQrcode, keyQrcode, mnemonicData, keyData, shareQrcodes, shareData, saveKeystoreFlag)
+	},
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import geth keystore or hex private key into ~/account",
+	Run: func(cmd *cobra.Command, args []string) {
+		initConfigIfExists()
+		if err := checkPwdMedia(true); err != nil {
+			log.Fatalln(err.Error())
+		}
+		if err := initKDFProfile(kdfProfileName); err != nil {
+			log.Fatalln(err.Error())
+		}
+		importAccountCmd(importKeystores, importKeys)
+	},
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export account as standard keystore with operator password",
+	Run: func(cmd *cobra.Command, args []string) {
+		initConfigIfExists()
+		if err := checkPwdMedia(true); err != nil {
+			log.Fatalln(err.Error())
+		}
+		exportKeystoreCmd(exportAddress, exportStrength, exportOutDir)
+	},
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify keystore, random passwords and qrcode of every account",
+	Run: func(cmd *cobra.Command, args []string) {
+		initConfigIfExists()
+		if err := checkPwdMedia(false); err != nil {
+			log.Fatalln(err.Error())
+		}
+		verifyAccountCmd(verifyFormat, verifyBatch)
+	},
+}
+
+var rotatePasswordsCmd = &cobra.Command{
+	Use:   "rotate-passwords",
+	Short: "Re-encrypt keystores with new random passwords",
+	Run: func(cmd *cobra.Command, args []string) {
+		initConfigIfExists()
+		if err := checkPwdMedia(true); err != nil {
+			log.Fatalln(err.Error())
+		}
+		if err := initKDFProfile(""); err != nil {
+			log.Fatalln(err.Error())
+		}
+		initSecretOptions(cmd)
+		rotateKeystorePwdCmd(rotateBatch, rotateAddresses)
 	},
 }
 
@@ -72,7 +267,7 @@ var syncCmd = &cobra.Command{
 	Short: "sync chain data to elasticsearch",
 	Run: func(cmd *cobra.Command, args []string) {
 		config.InitConfig()
-		sync()
+		syncChainData()
 	},
 }
 
@@ -85,16 +280,52 @@ var subscribeNewBlockCmd = &cobra.Command{
 	},
 }
 
+var deriveCmd = &cobra.Command{
+	Use:   "derive",
+	Short: "Derive watch-only addresses from xpub into db",
+	Run: func(cmd *cobra.Command, args []string) {
+		config.InitConfig()
+		if chainName != "" || config.Chain != "" {
+			if err := initChainProfile(chainName); err != nil {
+				log.Fatalln(err.Error())
+			}
+		}
+		deriveXpubCmd(xpubFile, xpubKey, xpubPath, deriveFrom, deriveCount)
+	},
+}
+
 var constructCmd = &cobra.Command{
 	Use:   "construct",
 	Short: "construct transactio",
 	Run: func(cmd *cobra.Command, args []string) {
 		config.InitConfig()
+		if err := initChainProfile(chainName); err != nil {
+			log.Fatalln(err.Error())
+		}
+		if feeMode != "" {
+			config.Fee.mode = feeMode
+		}
+		if err := config.Fee.validate(); err != nil {
+			log.Fatalln(err.Error())
+		}
 		if !Contains([]string{"geth", "parity", "etherscan"}, node) {
 			log.Errorln("Only support geth, parity, etherscan")
 			return
 		}
-		constructTxCmd()
+		if err := requireChainEndpoint(node); err != nil {
+			log.Fatalln(err.Error())
+		}
+		if sweepDust && len(sweepTokens) > 0 {
+			log.Fatalln("--dust can not be used with --token")
+		}
+		var tokens []*tokenConfig
+		if len(sweepTokens) > 0 {
+			var err error
+			if tokens, err = lookupTokens(sweepTokens); err != nil {
+				log.Fatalln(err.Error())
+			}
+		}
+		constructTxCmd(tokens)
 	},
 }
 
@@ -103,6 +334,9 @@ var signCmd = &cobra.Command{
 	Short: "sigin transactio",
 	Run: func(cmd *cobra.Command, args []string) {
 		config.InitConfig()
+		if err := initChainProfile(chainName); err != nil {
+			log.Fatalln(err.Error())
+		}
 		signTxCmd()
 	},
 }
@@ -112,6 +346,12 @@ var sendCmd = &cobra.Command{
 	Short: "broadcast signex transaction to ethereum network",
 	Run: func(cmd *cobra.Command, args []string) {
 		config.InitConfig()
+		if err := initChainProfile(chainName); err != nil {
+			log.Fatalln(err.Error())
+		}
+		if err := requireChainEndpoint("eth"); err != nil {
+			log.Fatalln(err.Error())
+		}
 		nodeClient, err := ethclient.Dial(config.EthRPC)
 		if err != nil {
 			log.Fatalln(err.Error())
@@ -127,6 +367,101 @@ func Execute() {
 	}
 }
 
+// initConfigIfExists offline commands work without configure file
+func initConfigIfExists() {
+	if configExists() {
+		config.InitConfig()
+	}
+}
+
+// initGenAccountOptions merge genaccount flags with configure and validate them, return the derivation path template
+func initGenAccountOptions(cmd *cobra.Command) *string {
+	initConfigIfExists()
+	if err := checkPwdMedia(true); err != nil {
+		log.Fatalln(err.Error())
+	}
+	if derivationPath == "" && derivationPreset == "" {
+		derivationPath, derivationPreset = config.DerivationPath, config.DerivationPreset
+	}
+	template, err := derivationTemplate(derivationPath, derivationPreset)
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+	if chainName != "" || config.Chain != "" {
+		if err := initChainProfile(chainName); err != nil {
+			log.Fatalln(err.Error())
+		}
+		// presets are for coin type 60, a custom path from flag or configure must use the coin type of the chain
+		if derivationPath == "" {
+			*template = withCoinType(*template, chain.coinType)
+		} else if err := checkCoinType(*template, chain.coinType); err != nil {
+			log.Fatalln(err.Error())
+		}
+		log.Infoln("chain", chain.name, "derivation path", *template)
+	}
+	if !cmd.Flags().Changed("bits") && config.MnemonicBits != 0 {
+		mnemonicBits = config.MnemonicBits
+	}
+	if !cmd.Flags().Changed("language") && config.MnemonicLanguage != "" {
+		mnemonicLanguage = config.MnemonicLanguage
+	}
+	if err := validateMnemonicBits(mnemonicBits); err != nil {
+		log.Fatalln(err.Error())
+	}
+	if err := setMnemonicLanguage(mnemonicLanguage); err != nil {
+		log.Fatalln(err.Error())
+	}
+	if err := initKDFProfile(kdfProfileName); err != nil {
+		log.Fatalln(err.Error())
+	}
+	log.Infoln("keystore kdf profile", keystoreKDF.String())
+	if exportXpub {
+		if _, _, err := splitXpubTemplate(*template); err != nil {
+			log.Fatalln(err.Error())
+		}
+	}
+	if paperFormat != "" && paperFormat != "pdf" && paperFormat != "svg" {
+		log.Fatalln("Only support paper format pdf, svg")
+	}
+	if !cmd.Flags().Changed("workers") {
+		workers = defaultWorkers(keystoreKDF)
+	} else if workers < 1 {
+		log.Fatalln("workers must be at least 1")
+	} else if limit := memoryWorkers(keystoreKDF); limit > 0 && workers > limit {
+		log.Warnln(workers, "workers may run out of memory, available memory fits", limit, "keystore KDF at a time")
+	}
+	log.Infoln("workers", workers)
+	if keyShares > 0 && (keyThreshold < 2 || keyThreshold > keyShares || keyShares > 255) {
+		log.Fatalln("key shares require 2 <= threshold <= shares <= 255")
+	}
+
+	initSecretOptions(cmd)
+	return template
+}
+
+// initSecretOptions merge random password flags with configure, commands without the flags take the configure values
+func initSecretOptions(cmd *cobra.Command) {
+	if !cmd.Flags().Changed("pwd-alphabet") && config.SecretAlphabet != "" {
+		pwdAlphabet = config.SecretAlphabet
+	}
+	if !cmd.Flags().Changed("first-pwd-length") && config.FirstPwdLength != 0 {
+		firstPwdLength = config.FirstPwdLength
+	}
+	if !cmd.Flags().Changed("second-pwd-length") && config.SecondPwdLength != 0 {
+		secondPwdLength = config.SecondPwdLength
+	}
+	if firstPwdLength < minSecretLength || secondPwdLength < minSecretLength {
+		log.Fatalln("random password length must be at least", minSecretLength)
+	}
+	var err error
+	if secret, err = newSecretGenerator(pwdAlphabet); err != nil {
+		log.Fatalln(err.Error())
+	}
+	if err := secretSelfTest(secret.alphabet, 32, 1000); err != nil {
+		log.Fatalln(err.Error())
+	}
+}
+
 func (conf *configure) InitConfig() {
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath(HomeDir())
@@ -144,6 +479,9 @@ func (conf *configure) InitConfig() {
 		log.Fatal("Error: ethereum-cold-wallet.yml not found in: ", HomeDir())
 	}
 
+	conf.Fee = defaultFeeStrategy()
+	conf.MaxTopUp = defaultMaxTopUp
+	conf.MaxTopUpTotal = defaultMaxTopUpTotal
 	for key, value := range viper.AllSettings() {
 		switch key {
 		case "elastic_url":
@@ -168,6 +506,60 @@ func (conf *configure) InitConfig() {
 			conf.GethRPC = value.(string)
 		case "parity_rpc":
 			conf.ParityRPC = value.(string)
+		case "derivation_path":
+			conf.DerivationPath = value.(string)
+		case "derivation_preset":
+			conf.DerivationPreset = value.(string)
+		case "mnemonic_bits":
+			conf.MnemonicBits = viper.GetInt(key)
+		case "mnemonic_language":
+			conf.MnemonicLanguage = value.(string)
+		case "random_pwd_first_root":
+			conf.RandomPwdFirstRoot = value.(string)
+		case "random_pwd_second_root":
+			conf.RandomPwdSecondRoot = value.(string)
+		case "secret_alphabet":
+			conf.SecretAlphabet = value.(string)
+		case "first_pwd_length":
+			conf.FirstPwdLength = viper.GetInt(key)
+		case "second_pwd_length":
+			conf.SecondPwdLength = viper.GetInt(key)
+		case "kdf_profile":
+			conf.KDFProfile = value.(string)
+		case "kdf_profiles":
+			conf.KDFProfiles = map[string]*kdfProfile{}
+			subv := viper.Sub(key)
+			for name := range subv.AllSettings() {
+				profilev := subv.Sub(name)
+				conf.KDFProfiles[name] = &kdfProfile{
+					name:    name,
+					kdf:     profilev.GetString("kdf"),
+					scryptN: profilev.GetInt("scrypt_n"),
+					scryptP: profilev.GetInt("scrypt_p"),
+					pbkdf2C: profilev.GetInt("pbkdf2_c"),
+				}
+			}
+		case "fee":
+			conf.Fee = parseFeeStrategy(viper.Sub(key))
+		case "tokens":
+			conf.Tokens = map[string]*tokenConfig{}
+			subv := viper.Sub(key)
+			for name := range subv.AllSettings() {
+				conf.Tokens[name] = parseTokenConfig(name, subv.Sub(name))
+			}
+		case "gas_station":
+			conf.GasStation = value.(string)
+		case "max_topup":
+			conf.MaxTopUp = viper.GetFloat64(key)
+		case "max_topup_total":
+			conf.MaxTopUpTotal = viper.GetFloat64(key)
+		case "chain":
+			conf.Chain = value.(string)
+		case "chains":
+			subv := viper.Sub(key)
+			for name := range subv.AllSettings() {
+				chainProfiles[name] = mergeChainProfile(name, subv.Sub(name))
+			}
 		case "etherscan_rpc":
 			subv := viper.Sub("etherscan_rpc")
 			for subKey, subValue := range subv.AllSettings() {
@@ -191,10 +583,75 @@ func init() {
 	rootCmd.AddCommand(constructCmd)
 	rootCmd.AddCommand(signCmd)
 	rootCmd.AddCommand(sendCmd)
+	rootCmd.AddCommand(recoverCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(rotatePasswordsCmd)
+	rootCmd.AddCommand(deriveCmd)
 	// rootCmd.AddCommand(syncCmd)
 	genAccountCmd.Flags().IntVarP(&number, "number", "n", 10, "Generate ethereum accounts")
 	genAccountCmd.MarkFlagRequired("number")
+	genAccountCmd.Flags().BoolVarP(&oneSeed, "seed", "s", false, "Derive all accounts from one mnemonic at consecutive indexes")
+	genAccountCmd.Flags().StringVarP(&derivationPath, "path", "p", "", "BIP32 derivation path template, x is the account index, e.g. m/44H/60H/0H/0/x")
+	genAccountCmd.Flags().StringVar(&derivationPreset, "preset", "", "Derivation path preset: bip44, metamask, ledgerlive, mew, electrum, legacy")
+	genAccountCmd.Flags().IntVar(&mnemonicBits, "bits", 128, "Mnemonic entropy bits: 128, 160, 192, 224, 256 (12 to 24 words)")
+	genAccountCmd.Flags().StringVar(&mnemonicLanguage, "language", "english", "Mnemonic word list: english, chinese_simplified, chinese_traditional, japanese, korean, french, italian, spanish")
+	genAccountCmd.Flags().StringVar(&pwdAlphabet, "pwd-alphabet", "letters", "Random password alphabet: letters, alnum, printable")
+	genAccountCmd.Flags().IntVar(&firstPwdLength, "first-pwd-length", 50, "Length of the first random password")
+	genAccountCmd.Flags().IntVar(&secondPwdLength, "second-pwd-length", 60, "Length of the second random password")
+	genAccountCmd.Flags().BoolVar(&withPassphrase, "passphrase", false, "Mix an operator BIP39 passphrase (25th word) into the seed")
+	genAccountCmd.Flags().BoolVar(&dualControl, "dual-control", false, "Two operators type the keystore password halves, no random password is written to disk")
+	genAccountCmd.Flags().StringVar(&vanityPrefix, "prefix", "", "Vanity address hex prefix, e.g. 0xC01D")
+	genAccountCmd.Flags().StringVar(&vanitySuffix, "suffix", "", "Vanity address hex suffix")
+	genAccountCmd.Flags().IntVarP(&workers, "workers", "w", 0, "Number of parallel workers, default CPUs up to 4 capped by the memory of the keystore KDF")
+	genAccountCmd.Flags().StringVar(&paperFormat, "paper", "", "Also write printable paper wallet of each mnemonic backup: pdf, svg")
+	genAccountCmd.Flags().BoolVar(&paperSplit, "paper-split", false, "Put encrypted mnemonic and key qrcode on separate paper documents")
+	genAccountCmd.Flags().BoolVar(&exportXpub, "xpub", false, "Also export the account level xpub of each mnemonic to ~/account/xpub")
+	genAccountCmd.Flags().StringVar(&kdfProfileName, "kdf", "", "Keystore KDF profile: standard, light, test or one of kdf_profiles in configure")
+	genAccountCmd.Flags().StringVar(&chainName, "chain", "", "Chain profile, its BIP44 coin type replaces 60 in the derivation path")
+	genAccountCmd.Flags().IntVar(&keyShares, "shares", 0, "Split the mnemonic AES decrypt key into N shamir shares qrcode")
+	genAccountCmd.Flags().IntVar(&keyThreshold, "threshold", 0, "Number of shares required to restore the AES decrypt key")
+
+	recoverCmd.Flags().StringVarP(&mnemonicQrcode, "mnemonic", "m", "", "*_aesdecrypt_mnemonic_marked.png file")
+	recoverCmd.Flags().StringVarP(&keyQrcode, "key", "k", "", "*_aesdecrypt_key_marked.png file")
+	recoverCmd.Flags().StringVar(&mnemonicData, "mnemonic-data", "", "Decoded text of the mnemonic qrcode, instead of --mnemonic")
+	recoverCmd.Flags().StringVar(&keyData, "key-data", "", "Decoded text of the key qrcode, instead of --key")
+	recoverCmd.Flags().StringSliceVar(&shareQrcodes, "share", []string{}, "*_aesdecrypt_key_share_*_marked.png files, instead of --key")
+	recoverCmd.Flags().StringSliceVar(&shareData, "share-data", []string{}, "Decoded text of the key share qrcodes")
+	recoverCmd.Flags().StringVar(&kdfProfileName, "kdf", "", "Keystore KDF profile of the recovered keystores")
+	recoverCmd.Flags().BoolVar(&saveKeystoreFlag, "keystore", false, "Write recovered accounts as fresh keystore and random passwords to ~/account")
+
+	importCmd.Flags().StringSliceVarP(&importKeystores, "keystore", "k", []string{}, "Geth keystore files to import")
+	importCmd.Flags().StringSliceVar(&importKeys, "private-key", []string{}, "Files which contain a hex private key to import")
+	importCmd.Flags().StringVar(&kdfProfileName, "kdf", "", "Keystore KDF profile of the imported keystores")
+
+	exportCmd.Flags().StringVarP(&exportAddress, "address", "a", "", "Address of the account to export")
+	exportCmd.MarkFlagRequired("address")
+	exportCmd.Flags().StringVar(&exportStrength, "scrypt", "standard", "Scrypt strength of exported keystore: standard, light")
+	exportCmd.Flags().StringVarP(&exportOutDir, "out", "o", "", "Output directory, default ~/account/export")
+
+	verifyCmd.Flags().StringVarP(&verifyFormat, "format", "f", "table", "Report format: table, json")
+	verifyCmd.Flags().StringVarP(&verifyBatch, "batch", "b", "", "Only verify this batch directory, e.g. version_1_2018-08-13_15-40-10")
+
+	rotatePasswordsCmd.Flags().StringVarP(&rotateBatch, "batch", "b", "", "Batch directory name, e.g. version_1_2018-08-13_15-40-10")
+	rotatePasswordsCmd.Flags().StringSliceVarP(&rotateAddresses, "address", "a", []string{}, "Addresses to rotate")
+
+	deriveCmd.Flags().StringVarP(&xpubFile, "file", "f", "", "*_xpub.json file exported by genaccount --xpub")
+	deriveCmd.Flags().StringVar(&xpubKey, "xpub", "", "Account level xpub, instead of --file")
+	deriveCmd.Flags().StringVarP(&xpubPath, "path", "p", "0/x", "Derivation path relative to --xpub, x is the address index")
+	deriveCmd.Flags().Uint32Var(&deriveFrom, "from", 0, "First address index")
+	deriveCmd.Flags().Uint32VarP(&deriveCount, "number", "n", 20, "Number of addresses to derive")
+	deriveCmd.Flags().StringVar(&chainName, "chain", "", "Chain profile, the xpub path must use its BIP44 coin type, default chain in configure")
 
 	constructCmd.Flags().StringVarP(&node, "node", "n", "parity", "Ethereum node type, support geth, parity, etherscan")
 	constructCmd.MarkFlagRequired("node")
+	constructCmd.Flags().StringVar(&chainName, "chain", "", "Chain profile: mainnet, privatenet, sepolia, holesky, classic, bsc or one of chains in configure, default chain or net_mode in configure")
+	constructCmd.Flags().BoolVar(&withAccessList, "access-list", false, "Fill EIP-2930 access list and gas limit by eth_createAccessList of geth or parity")
+	constructCmd.Flags().StringSliceVar(&sweepTokens, "token", []string{}, "Sweep these ERC-20 tokens of tokens in configure instead of the native balance, all for every token")
+	constructCmd.Flags().BoolVar(&sweepDust, "dust", false, "Sweep the native balance left by gas top-ups of token sweeps, regardless of max_balance")
+	constructCmd.Flags().StringVar(&feeMode, "fee", "", "Fee mode: legacy, dynamic (EIP-1559), default fee mode in configure")
+	signCmd.Flags().StringVar(&chainName, "chain", "", "Chain profile, default chain or net_mode in configure")
+	sendCmd.Flags().StringVar(&chainName, "chain", "", "Chain profile, default chain or net_mode in configure")
+	sendCmd.Flags().DurationVar(&fundingWait, "wait", 10*time.Minute, "How long to wait for a gas top-up to be mined before its token transfers are sent")
 }
@@ -17,6 +17,21 @@ import (
 type SubAddress struct {
 	gorm.Model
 	Address string `gorm:"type:varchar(42);not null;unique_index"`
+	// DerivationIndex and DerivationPath are set for addresses derived from Xpub
+	DerivationIndex *uint32
+	DerivationPath  string `gorm:"type:varchar(64)"`
+	Xpub            string `gorm:"type:varchar(120);index"`
+}
+
+// GasDust 代币归集充值手续费后留在地址上的 ETH，待原生币归集
+type GasDust struct {
+	gorm.Model
+	Address      string `gorm:"type:varchar(42);not null;index"`
+	Station      string `gorm:"type:varchar(42)"`
+	FundingNonce uint64
+	// TopUp in wei
+	TopUp string `gorm:"type:varchar(78)"`
+	Swept bool   `gorm:"index"`
 }
 
 type ormBbAlias struct {
@@ -37,7 +52,7 @@ func dbConn() *gorm.DB {
 
 // DBMigrate 数据库表迁移
 func (db ormBbAlias) DBMigrate() {
-	db.AutoMigrate(&SubAddress{})
+	db.AutoMigrate(&SubAddress{}, &GasDust{})
 }
 
 func (db ormBbAlias) csv2db() {
@@ -62,7 +77,21 @@ func (db ormBbAlias) csv2db() {
 	log.Info("csv2db done")
 }
 
-func (db ormBbAlias) constructTxField(address string) (*string, *big.Int, *uint64, *big.Int, error) {
+func (db ormBbAlias) xpub2db(xpub string, addresses []*xpubAddress) {
+	for _, address := range addresses {
+		index := address.index
+		subAddress := SubAddress{}
+		db.Where(SubAddress{Address: address.address}).Assign(SubAddress{
+			DerivationIndex: &index,
+			DerivationPath:  address.path,
+			Xpub:            xpub,
+		}).FirstOrCreate(&subAddress)
+		log.Infoln("derive address", address.address, "at", address.path)
+	}
+	log.Info("xpub2db done")
+}
+
+func (db ormBbAlias) constructTxField(address string) (*string, *big.Int, *uint64, *chainFee, error) {
 	subAddress, err := db.getSubAddress(address)
 	if err != nil {
 		return nil, nil, nil, nil, err
@@ -70,23 +99,23 @@ func (db ormBbAlias) constructTxField(address string) (*string, *big.Int, *uint6
 
 	switch node {
 	case "geth":
-		balance, nonce, gasPrice, err := nodeConstructTxField("geth", *subAddress)
+		balance, nonce, fee, err := nodeConstructTxField("geth", *subAddress)
 		if err != nil {
 			return nil, nil, nil, nil, err
 		}
-		return subAddress, balance, nonce, gasPrice, nil
+		return subAddress, balance, nonce, fee, nil
 	case "parity":
-		balance, nonce, gasPrice, err := nodeConstructTxField("parity", *subAddress)
+		balance, nonce, fee, err := nodeConstructTxField("parity", *subAddress)
 		if err != nil {
 			return nil, nil, nil, nil, err
 		}
-		return subAddress, balance, nonce, gasPrice, nil
+		return subAddress, balance, nonce, fee, nil
 	case "etherscan":
-		balance, nonce, gasPrice, err := etherscan.etherscanConstructTxField(*subAddress)
+		balance, nonce, fee, err := etherscan.etherscanConstructTxField(*subAddress)
 		if err != nil {
 			return nil, nil, nil, nil, err
 		}
-		return subAddress, balance, nonce, gasPrice, nil
+		return subAddress, balance, nonce, fee, nil
 	default:
 		return nil, nil, nil, nil, errors.New("Only support geth, parity, etherscan")
 	}
@@ -100,3 +129,49 @@ func (db ormBbAlias) getSubAddress(address string) (*string, error) {
 	}
 	return &(subAddress.Address), nil
 }
+
+func (db ormBbAlias) recordGasDust(address, station string, fundingNonce uint64, topUp *big.Int) {
+	db.Create(&GasDust{
+		Address:      address,
+		Station:      station,
+		FundingNonce: fundingNonce,
+		TopUp:        topUp.String(),
+	})
+}
+
+// constructDustTxs sweep the native balance of topped up addresses, the max_balance threshold does not apply.
+// Run it after the token transfers are mined. An address is marked swept on a later run, once its balance no
+// longer covers the fee of a sweep, until then every run exports the sweep again.
+func (db ormBbAlias) constructDustTxs() {
+	var dusts []*GasDust
+	db.Where("swept = ?", false).Find(&dusts)
+
+	checked := map[string]bool{}
+	for _, dust := range dusts {
+		if checked[strings.ToLower(dust.Address)] {
+			continue
+		}
+		checked[strings.ToLower(dust.Address)] = true
+
+		balance, nonce, fee, err := accountState(dust.Address)
+		if err != nil {
+			log.Warnln(err.Error())
+			continue
+		}
+		feeCap, _, err := feePerGas(fee)
+		if err != nil {
+			log.Warnln(err.Error())
+			continue
+		}
+		// the max fee left over by the dynamic fee sweep is not worth another one
+		if balance.Cmp(new(big.Int).Mul(feeCap, big.NewInt(21000))) <= 0 {
+			db.Model(&GasDust{}).Where("address = ? AND swept = ?", dust.Address, false).Update("swept", true)
+			log.Infoln("dust of", dust.Address, "swept, balance", balance.String())
+			continue
+		}
+		to := randomPickFromSlice(config.To)
+		if err := constructRawTx(balance, fee, nonce, dust.Address, to); err != nil {
+			log.Warnln("dust of", dust.Address, err.Error())
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+// defaultDerivationPath BIP44 以太坊默认派生路径模板，x 为账户序号
+const defaultDerivationPath = "m/44H/60H/0H/0/x"
+
+// derivationPresets 兼容其他钱包的派生路径模板
+var derivationPresets = map[string]string{
+	"bip44":      defaultDerivationPath,
+	"metamask":   defaultDerivationPath,
+	"ledgerlive": "m/44H/60H/xH/0/0",
+	"mew":        "m/44H/60H/0H/x",
+	"electrum":   "m/44H/60H/0H/x",
+	"legacy":     "m/44H/60H/0H/x",
+}
+
+// derivationTemplate pick the derivation path template, a custom path takes precedence over the preset
+func derivationTemplate(path, preset string) (*string, error) {
+	template := defaultDerivationPath
+	if path != "" {
+		template = path
+	} else if preset != "" {
+		presetPath, ok := derivationPresets[strings.ToLower(preset)]
+		if !ok {
+			return nil, errors.New(strings.Join([]string{"unknown derivation preset:", preset}, " "))
+		}
+		template = presetPath
+	}
+
+	if _, _, err := parseDerivationPath(template, 0); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// parseDerivationPath resolve the template with index, return the child numbers and the path actually used.
+// Both H and ' mark a hardened component, x is replaced by the account index.
+func parseDerivationPath(template string, index uint32) ([]uint32, *string, error) {
+	components := strings.Split(strings.TrimSpace(template), "/")
+	if len(components) < 2 || components[0] != "m" {
+		return nil, nil, errors.New(strings.Join([]string{"invalid derivation path:", template}, " "))
+	}
+
+	children := []uint32{}
+	pathComponents := []string{"m"}
+	for _, component := range components[1:] {
+		hardened := false
+		if strings.HasSuffix(component, "H") || strings.HasSuffix(component, "h") || strings.HasSuffix(component, "'") {
+			hardened = true
+			component = component[:len(component)-1]
+		}
+
+		var child uint32
+		if component == "x" {
+			child = index
+		} else {
+			value, err := strconv.ParseUint(component, 10, 32)
+			if err != nil {
+				return nil, nil, errors.New(strings.Join([]string{"invalid derivation path:", template, err.Error()}, " "))
+			}
+			child = uint32(value)
+		}
+		if child >= hdkeychain.HardenedKeyStart {
+			return nil, nil, errors.New(strings.Join([]string{"derivation path component out of range:", template}, " "))
+		}
+
+		pathComponent := strconv.FormatUint(uint64(child), 10)
+		if hardened {
+			child += hdkeychain.HardenedKeyStart
+			pathComponent = strings.Join([]string{pathComponent, "H"}, "")
+		}
+		children = append(children, child)
+		pathComponents = append(pathComponents, pathComponent)
+	}
+
+	path := strings.Join(pathComponents, "/")
+	return children, &path, nil
+}
+
+// isIndexedDerivationPath tells whether the template derives different accounts by index
+func isIndexedDerivationPath(template string) bool {
+	for _, component := range strings.Split(template, "/") {
+		if strings.TrimRight(component, "Hh'") == "x" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// minOperatorPwdLength shortest password half an operator may type
+const minOperatorPwdLength = 12
+
+// operatorPwds password halves typed by the two operators, cached per batch for the current run only
+var operatorPwds = struct {
+	sync.Mutex
+	halves map[string][2]string
+}{halves: map[string][2]string{}}
+
+// promptOperatorPwds collect the first and second password halves of a dual control batch through two
+// separate prompts, neither half is ever written to disk
+func promptOperatorPwds(timeDir string) (string, string, error) {
+	operatorPwds.Lock()
+	defer operatorPwds.Unlock()
+	if halves, ok := operatorPwds.halves[timeDir]; ok {
+		return halves[0], halves[1], nil
+	}
+
+	first, err := promptLabeledPwd(strings.Join([]string{"First operator password of", timeDir}, " "))
+	if err != nil {
+		return "", "", err
+	}
+	second, err := promptLabeledPwd(strings.Join([]string{"Second operator password of", timeDir}, " "))
+	if err != nil {
+		return "", "", err
+	}
+	if len(*first) < minOperatorPwdLength || len(*second) < minOperatorPwdLength {
+		return "", "", errors.New(strings.Join([]string{"operator password must be at least", strconv.Itoa(minOperatorPwdLength), "characters"}, " "))
+	}
+	if *first == *second {
+		return "", "", errors.New("the two operator passwords must differ")
+	}
+
+	operatorPwds.halves[timeDir] = [2]string{*first, *second}
+	return *first, *second, nil
+}
@@ -56,6 +56,35 @@ func (es EtherScan) getBalance(address string) (*big.Int, error) {
 	return nil, errors.New("etherscan get balance error")
 }
 
+func (es EtherScan) getTokenBalance(contract, address string) (*big.Int, error) {
+	resp, body, err := request.Get(etherscan.URL).Query(map[string]interface{}{
+		"module":          "account",
+		"action":          "tokenbalance",
+		"contractaddress": contract,
+		"address":         address,
+		"tag":             "latest",
+		"apikey":          APIKEY,
+	}).End()
+
+	if err != nil {
+		return nil, errors.New(strings.Join([]string{"etherscan: get token balance error:", address, err[0].Error()}, " "))
+	}
+	if handleStatus(resp) {
+		var (
+			respBody = new(AccountRespBody)
+			balance  = new(big.Int)
+		)
+		if err := json.Unmarshal([]byte(body), respBody); err != nil {
+			return nil, errors.New("etherscan getTokenBalance Unmarshal error")
+		}
+		if _, ok := balance.SetString(respBody.Result, 10); !ok {
+			return nil, errors.New(strings.Join([]string{"etherscan: invalid token balance", respBody.Result}, " "))
+		}
+		return balance, nil
+	}
+	return nil, errors.New("etherscan get token balance error")
+}
+
 func (es EtherScan) getGasPrice() (*big.Int, error) {
 	resp, body, err := request.Get(etherscan.URL).Query(map[string]interface{}{
 		"module": "proxy",
@@ -74,8 +103,11 @@ func (es EtherScan) getGasPrice() (*big.Int, error) {
 		if err := json.Unmarshal([]byte(body), respBody); err != nil {
 			return nil, errors.New("etherscan getBalance Unmarshal error")
 		}
+		// proxy result is hex quantity
 		resultWithoutHex := strings.Replace(respBody.Result, "0x", "", -1)
-		gasPrice.SetString(resultWithoutHex, 10)
+		if _, ok := gasPrice.SetString(resultWithoutHex, 16); !ok {
+			return nil, errors.New(strings.Join([]string{"etherscan: invalid gasPrice", respBody.Result}, " "))
+		}
 		return gasPrice, nil
 	}
 	return nil, errors.New("etherscan get gasPrice error")
@@ -105,7 +137,47 @@ func (es EtherScan) getAccountNonce(address string) (*uint64, error) {
 	return nil, errors.New("etherscan get account nonce error")
 }
 
-func (es EtherScan) etherscanConstructTxField(address string) (*big.Int, *uint64, *big.Int, error) {
+// BlockRespBody EtherScan eth_getBlockByNumber response body
+type BlockRespBody struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int    `json:"id"`
+	Result  struct {
+		BaseFeePerGas string `json:"baseFeePerGas"`
+	} `json:"result"`
+}
+
+func (es EtherScan) getBaseFee() (*big.Int, error) {
+	resp, body, err := request.Get(etherscan.URL).Query(map[string]interface{}{
+		"module":  "proxy",
+		"action":  "eth_getBlockByNumber",
+		"tag":     "latest",
+		"boolean": "false",
+		"apikey":  APIKEY,
+	}).End()
+
+	if err != nil {
+		return nil, errors.New(strings.Join([]string{"etherscan: get latest block error:", err[0].Error()}, " "))
+	}
+	if handleStatus(resp) {
+		var (
+			respBody = new(BlockRespBody)
+			baseFee  = new(big.Int)
+		)
+		if err := json.Unmarshal([]byte(body), respBody); err != nil {
+			return nil, errors.New("etherscan getBaseFee Unmarshal error")
+		}
+		if respBody.Result.BaseFeePerGas == "" {
+			return nil, errors.New("etherscan: latest block has no base fee")
+		}
+		if _, ok := baseFee.SetString(strings.Replace(respBody.Result.BaseFeePerGas, "0x", "", -1), 16); !ok {
+			return nil, errors.New(strings.Join([]string{"etherscan: invalid baseFeePerGas", respBody.Result.BaseFeePerGas}, " "))
+		}
+		return baseFee, nil
+	}
+	return nil, errors.New("etherscan get base fee error")
+}
+
+func (es EtherScan) etherscanConstructTxField(address string) (*big.Int, *uint64, *chainFee, error) {
 	balance, err := es.getBalance(address)
 	if err != nil {
 		return nil, nil, nil, err
@@ -121,12 +193,35 @@ func (es EtherScan) etherscanConstructTxField(address string) (*big.Int, *uint64
 		return nil, nil, nil, errors.New(strings.Join([]string{"etherscan: get account nonce error:", address, err.Error()}, " "))
 	}
 
-	gasPrice, err := es.getGasPrice()
+	fee, err := es.etherscanChainFee()
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
-	return balance, accountNonce, gasPrice, nil
+	return balance, accountNonce, fee, nil
+}
+
+func (es EtherScan) etherscanChainFee() (*chainFee, error) {
+	gasPrice, err := es.getGasPrice()
+	if err != nil {
+		return nil, err
+	}
+	fee := &chainFee{gasPrice: gasPrice}
+
+	if config.Fee.mode == "dynamic" {
+		baseFee, err := es.getBaseFee()
+		if err != nil {
+			return nil, err
+		}
+		// eth_gasPrice is base fee plus the suggested tip
+		fee.baseFee = baseFee
+		if gasPrice.Cmp(baseFee) > 0 {
+			fee.gasTipCap = new(big.Int).Sub(gasPrice, baseFee)
+		} else {
+			fee.gasTipCap = new(big.Int)
+		}
+	}
+	return fee, nil
 }
 
 func handleStatus(resp gorequest.Response) bool {
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	log "github.com/sirupsen/logrus"
+)
+
+// exportScryptParams scrypt strength of the exported standard keystore
+var exportScryptParams = map[string][2]int{
+	"standard": {keystore.StandardScryptN, keystore.StandardScryptP},
+	"light":    {keystore.LightScryptN, keystore.LightScryptP},
+}
+
+// exportKeystoreCmd decrypt the account with the two random passwords and write
+// a Web3 Secret Storage v3 keystore encrypted with the password typed by operator
+func exportKeystoreCmd(address, strength, outDir string) {
+	scryptParams, ok := exportScryptParams[strings.ToLower(strength)]
+	if !ok {
+		log.Fatalln("Only support scrypt strength standard, light")
+	}
+
+	key, err := decodeKS2Key(address)
+	if err != nil {
+		log.Fatalln(strings.Join([]string{"decode keystore to key error:", err.Error()}, " "))
+	}
+
+	fmt.Println("Enter the password of exported keystore")
+	pwd, err := promptPwd()
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+
+	keyjson, err := keystore.EncryptKey(key, *pwd, scryptParams[0], scryptParams[1])
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+
+	exportFile, err := saveExportKeystore(key.Address.Hex(), outDir, keyjson)
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+
+	if err := auditLog("export", log.Fields{
+		"address": key.Address.Hex(),
+		"scrypt":  strength,
+		"file":    *exportFile,
+	}); err != nil {
+		// no export without an audit entry
+		if rmErr := os.Remove(*exportFile); rmErr != nil {
+			log.Fatalln("write audit log error", err.Error(), "and remove", *exportFile, "error", rmErr.Error())
+		}
+		log.Fatalln("write audit log error, removed", *exportFile, err.Error())
+	}
+	log.Warnln("Exported keystore of", key.Address.Hex(), "to", *exportFile)
+}
+
+func saveExportKeystore(address, outDir string, keyjson []byte) (*string, error) {
+	if outDir == "" {
+		outDir = strings.Join([]string{HomeDir(), "account", "export"}, "/")
+	}
+	exportPath, err := mkdirBySlice([]string{outDir})
+	if err != nil {
+		return nil, errors.New(strings.Join([]string{"Could not create directory", err.Error()}, " "))
+	}
+
+	exportName := strings.Join([]string{address, "json"}, ".")
+	exportFile := strings.Join([]string{*exportPath, exportName}, "/")
+	if err := ioutil.WriteFile(exportFile, keyjson, 0600); err != nil {
+		return nil, errors.New(strings.Join([]string{"Failed to write keyfile to", err.Error()}, " "))
+	}
+	return &exportFile, nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// feeStrategy 交易手续费策略
+type feeStrategy struct {
+	// mode legacy gas price or dynamic EIP-1559 type 2 transaction
+	mode string
+	// priority suggest takes the node's suggested tip capped by priorityGwei, fixed always pays priorityGwei
+	priority     string
+	priorityGwei float64
+	// maxFee base multiplies the latest base fee by baseFeeMultiplier then adds the tip, capped by maxFeeGwei,
+	// fixed always pays maxFeeGwei
+	maxFee            string
+	baseFeeMultiplier float64
+	maxFeeGwei        float64
+}
+
+// chainFee fee market of the chain when the transaction is constructed, baseFee is nil before London
+type chainFee struct {
+	gasPrice  *big.Int
+	baseFee   *big.Int
+	gasTipCap *big.Int
+}
+
+func defaultFeeStrategy() *feeStrategy {
+	return &feeStrategy{
+		mode:              "legacy",
+		priority:          "suggest",
+		priorityGwei:      2,
+		maxFee:            "base",
+		baseFeeMultiplier: 2,
+	}
+}
+
+// parseFeeStrategy read the fee block of configure
+func parseFeeStrategy(v *viper.Viper) *feeStrategy {
+	s := defaultFeeStrategy()
+	if v.IsSet("mode") {
+		s.mode = v.GetString("mode")
+	}
+	if v.IsSet("priority") {
+		s.priority = v.GetString("priority")
+	}
+	if v.IsSet("priority_gwei") {
+		s.priorityGwei = v.GetFloat64("priority_gwei")
+	}
+	if v.IsSet("max_fee") {
+		s.maxFee = v.GetString("max_fee")
+	}
+	if v.IsSet("base_fee_multiplier") {
+		s.baseFeeMultiplier = v.GetFloat64("base_fee_multiplier")
+	}
+	if v.IsSet("max_fee_gwei") {
+		s.maxFeeGwei = v.GetFloat64("max_fee_gwei")
+	}
+	return s
+}
+
+func (s *feeStrategy) validate() error {
+	switch s.mode {
+	case "legacy":
+		return nil
+	case "dynamic":
+	default:
+		return errors.New("Only support fee mode legacy, dynamic")
+	}
+	if s.priority != "suggest" && s.priority != "fixed" {
+		return errors.New("Only support priority fee strategy suggest, fixed")
+	}
+	if s.priorityGwei <= 0 {
+		return errors.New("priority_gwei must be positive")
+	}
+	switch s.maxFee {
+	case "base":
+		if s.baseFeeMultiplier < 1 {
+			return errors.New("base_fee_multiplier must be at least 1")
+		}
+	case "fixed":
+		if s.maxFeeGwei <= 0 {
+			return errors.New("fixed max fee requires max_fee_gwei")
+		}
+	default:
+		return errors.New("Only support max fee strategy base, fixed")
+	}
+	return nil
+}
+
+// dynamicFees max priority fee and max fee per gas of a type 2 transaction
+func (s *feeStrategy) dynamicFees(fee *chainFee) (*big.Int, *big.Int, error) {
+	if fee.baseFee == nil {
+		return nil, nil, errors.New("chain has no base fee, use legacy fee mode")
+	}
+
+	tip := gweiToWei(s.priorityGwei)
+	if s.priority == "suggest" && fee.gasTipCap != nil && fee.gasTipCap.Cmp(tip) < 0 {
+		tip = new(big.Int).Set(fee.gasTipCap)
+	}
+
+	var maxFee *big.Int
+	if s.maxFee == "fixed" {
+		maxFee = gweiToWei(s.maxFeeGwei)
+	} else {
+		baseFee, _ := new(big.Float).Mul(new(big.Float).SetInt(fee.baseFee), big.NewFloat(s.baseFeeMultiplier)).Int(nil)
+		maxFee = new(big.Int).Add(baseFee, tip)
+		if s.maxFeeGwei > 0 && maxFee.Cmp(gweiToWei(s.maxFeeGwei)) > 0 {
+			maxFee = gweiToWei(s.maxFeeGwei)
+		}
+	}
+
+	if maxFee.Cmp(tip) < 0 {
+		return nil, nil, errors.New("max fee is lower than the priority fee")
+	}
+	if maxFee.Cmp(fee.baseFee) < 0 {
+		return nil, nil, errors.New(strings.Join([]string{"max fee", maxFee.String(), "is lower than the base fee", fee.baseFee.String()}, " "))
+	}
+	return tip, maxFee, nil
+}
+
+func gweiToWei(gwei float64) *big.Int {
+	wei, _ := new(big.Float).Mul(big.NewFloat(gwei), big.NewFloat(1e9)).Int(nil)
+	return wei
+}
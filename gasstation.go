@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultMaxTopUp largest gas top-up of one deposit address, in native currency
+	defaultMaxTopUp = 0.05
+	// defaultMaxTopUpTotal largest sum of the top-ups of one construct, sign or send run
+	defaultMaxTopUpTotal = 0.5
+	// fundingPollInterval interval of polling the receipt of a funding tx
+	fundingPollInterval = 5 * time.Second
+)
+
+// gasStation 为代币归集地址充值手续费的地址，同一次 construct 的充值交易使用连续 nonce
+type gasStation struct {
+	address  string
+	balance  *big.Int
+	nonce    uint64
+	fee      *chainFee
+	maxTopUp *big.Int
+	// total top-ups of this run, capped by maxTotal
+	total    *big.Int
+	maxTotal *big.Int
+}
+
+func newGasStation() (*gasStation, error) {
+	if !common.IsHexAddress(config.GasStation) {
+		return nil, errors.New(strings.Join([]string{"invalid gas_station address", config.GasStation}, " "))
+	}
+	address := common.HexToAddress(config.GasStation).Hex()
+	balance, nonce, fee, err := accountState(address)
+	if err != nil {
+		return nil, errors.New(strings.Join([]string{"gas station", address, err.Error()}, " "))
+	}
+	log.Infoln("gas station", address, "balance", balance.String(), "nonce", *nonce)
+	return &gasStation{
+		address:  address,
+		balance:  balance,
+		nonce:    *nonce,
+		fee:      fee,
+		maxTopUp: toWei(config.MaxTopUp),
+		total:    new(big.Int),
+		maxTotal: toWei(config.MaxTopUpTotal),
+	}, nil
+}
+
+// fund export the funding tx of the deposit address, it is paired with the token transfers by the file name
+// unsign_from.<station>.fund.<address>.json, return the nonce of the funding tx
+func (s *gasStation) fund(to string, value *big.Int) (*uint64, error) {
+	if value.Cmp(s.maxTopUp) > 0 {
+		return nil, errors.New(strings.Join([]string{"top-up", value.String(), "exceeds max_topup", s.maxTopUp.String()}, " "))
+	}
+	total := new(big.Int).Add(s.total, value)
+	if total.Cmp(s.maxTotal) > 0 {
+		return nil, errors.New(strings.Join([]string{"top-up", value.String(), "of", to, "brings the total over max_topup_total", s.maxTotal.String()}, " "))
+	}
+
+	gasLimit := uint64(21000) // in units
+	feeCap, _, err := feePerGas(s.fee)
+	if err != nil {
+		return nil, err
+	}
+	cost := new(big.Int).Add(value, new(big.Int).Mul(feeCap, new(big.Int).SetUint64(gasLimit)))
+	if s.balance.Cmp(cost) < 0 {
+		return nil, errors.New(strings.Join([]string{"gas station balance", s.balance.String(), "does not cover", cost.String()}, " "))
+	}
+
+	tx, err := newTx(s.nonce, s.fee, gasLimit, nil, common.HexToAddress(to), value, nil)
+	if err != nil {
+		return nil, err
+	}
+	rawTxHex, err := encodeTx(tx)
+	if err != nil {
+		return nil, errors.New(strings.Join([]string{"encode raw tx error", err.Error()}, " "))
+	}
+	if err := exportHexTx(&Tx{
+		From:  s.address,
+		To:    to,
+		TxHex: *rawTxHex,
+		Value: *value,
+		Nonce: s.nonce,
+		Hash:  tx.Hash().Hex(),
+		Type:  tx.Type(),
+		Funds: to,
+	}, false); err != nil {
+		return nil, err
+	}
+	log.Infoln("construct gas top-up", value.String(), "from", s.address, "to", to, "nonce", s.nonce)
+
+	nonce := s.nonce
+	s.nonce++
+	s.balance.Sub(s.balance, cost)
+	s.total = total
+	return &nonce, nil
+}
+
+// checkFundingTx a funding tx is sent by the gas station to the address it funds, carries no call data and at most max_topup
+func checkFundingTx(simpletx *Tx, recipient *common.Address, token *tokenConfig, value *big.Int) error {
+	if !common.IsHexAddress(config.GasStation) || !strings.EqualFold(simpletx.From, config.GasStation) {
+		return errors.New(strings.Join([]string{"funding tx from", simpletx.From, "which is not gas_station in configure"}, " "))
+	}
+	if token != nil || !strings.EqualFold(simpletx.Funds, recipient.Hex()) || strings.EqualFold(simpletx.Funds, config.GasStation) {
+		return errors.New(strings.Join([]string{"funding tx of", simpletx.Funds, "does not match txhex"}, " "))
+	}
+	if value.Cmp(toWei(config.MaxTopUp)) > 0 {
+		return errors.New(strings.Join([]string{"funding tx value", value.String(), "exceeds max_topup"}, " "))
+	}
+	return nil
+}
+
+// checkDepositAddress the address a funding tx tops up must be a deposit address generated on this machine,
+// its keystore is in ~/account/keystore or it is listed in ~/account/eth_address.csv
+func checkDepositAddress(address string) error {
+	if _, err := accountDir(address); err == nil {
+		return nil
+	}
+	addresses, err := readCSVAddresses(strings.Join([]string{HomeDir(), "account"}, "/"))
+	if err != nil {
+		return errors.New(strings.Join([]string{"read eth_address.csv error", err.Error()}, " "))
+	}
+	for _, a := range addresses {
+		if strings.EqualFold(a.Address, address) {
+			return nil
+		}
+	}
+	return errors.New(strings.Join([]string{"funding tx of", address, "which is not a deposit address of this wallet"}, " "))
+}
+
+// fundingTotal count and sum the value of the funding txs, a tx which can not be decoded is left to sign or send
+func fundingTotal(txs []*Tx) (int, *big.Int) {
+	count, total := 0, new(big.Int)
+	for _, simpletx := range txs {
+		if simpletx.Funds == "" {
+			continue
+		}
+		tx, err := decodeTx(simpletx.TxHex)
+		if err != nil {
+			continue
+		}
+		count++
+		total.Add(total, tx.Value())
+	}
+	return count, total
+}
+
+// checkFundingTotal the top-ups of one run must not exceed max_topup_total
+func checkFundingTotal(total *big.Int) error {
+	if total.Cmp(toWei(config.MaxTopUpTotal)) > 0 {
+		return errors.New(strings.Join([]string{"funding txs total", total.String(), "exceeds max_topup_total", toWei(config.MaxTopUpTotal).String()}, " "))
+	}
+	return nil
+}
+
+// formatNative amount of native currency from its smallest unit
+func formatNative(value *big.Int) string {
+	return decimal.NewFromBigInt(value, -chain.decimals).String()
+}
+
+// toWei amount of native currency in its smallest unit
+func toWei(amount float64) *big.Int {
+	wei, _ := new(big.Int).SetString(decimal.NewFromFloat(amount).Mul(decimal.New(1, chain.decimals)).Truncate(0).String(), 10)
+	return wei
+}
+
+// fundingKey identify a funding tx by the gas station and nonce, the hash changes when it is signed
+func fundingKey(station string, nonce uint64) string {
+	return strings.Join([]string{strings.ToLower(station), strconv.FormatUint(nonce, 10)}, ".")
+}
+
+// waitMined poll the receipt until the tx is mined or timeout, a reverted tx is an error
+func waitMined(nodeClient *ethclient.Client, hash string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		receipt, err := nodeClient.TransactionReceipt(context.Background(), common.HexToHash(hash))
+		if err == nil {
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				return errors.New(strings.Join([]string{"funding tx", hash, "failed"}, " "))
+			}
+			return nil
+		}
+		if err != ethereum.NotFound {
+			return errors.New(strings.Join([]string{"get receipt of", hash, "error", err.Error()}, " "))
+		}
+		if time.Now().After(deadline) {
+			return errors.New(strings.Join([]string{"funding tx", hash, "not mined in", timeout.String()}, " "))
+		}
+		time.Sleep(fundingPollInterval)
+	}
+}
+
+// waitFundingTxs wait for the funding txs concurrently, return the result by funding key
+func waitFundingTxs(nodeClient *ethclient.Client, funding map[string]string, timeout time.Duration) map[string]error {
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	results := map[string]error{}
+	for key, hash := range funding {
+		wg.Add(1)
+		go func(key, hash string) {
+			defer wg.Done()
+			err := waitMined(nodeClient, hash, timeout)
+			mu.Lock()
+			results[key] = err
+			mu.Unlock()
+		}(key, hash)
+	}
+	wg.Wait()
+	return results
+}
+
+// sentFundingTx tells whether the node already knows the signed funding tx, pending or mined by an earlier send
+func sentFundingTx(nodeClient *ethclient.Client, simpletx *Tx) (string, bool) {
+	tx, err := decodeTx(simpletx.TxHex)
+	if err != nil {
+		return "", false
+	}
+	if _, _, err := nodeClient.TransactionByHash(context.Background(), tx.Hash()); err != nil {
+		return "", false
+	}
+	return tx.Hash().Hex(), true
+}
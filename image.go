@@ -16,6 +16,8 @@ import (
 	"image/draw"
 	"image/png"
 
+	"github.com/makiuchi-d/gozxing"
+	gozxingqrcode "github.com/makiuchi-d/gozxing/qrcode"
 	"gonum.org/v1/plot/vg"
 	"gonum.org/v1/plot/vg/vgimg"
 	// "gonum.org/v1/plot/plotter"
@@ -99,6 +101,7 @@ func wm(target, address, qrcodeType string) {
 	if err != nil {
 		log.Fatalln(err.Error())
 	}
+	defer srcFile.Close()
 
 	srcImage, _, err := image.Decode(srcFile)
 	if err != nil {
@@ -124,5 +127,41 @@ func wm(target, address, qrcodeType string) {
 	if err != nil {
 		log.Fatalln(err.Error())
 	}
-	png.Encode(f, rgba)
+	defer f.Close()
+	if err := png.Encode(f, rgba); err != nil {
+		log.Fatalln(err.Error())
+	}
+}
+
+// decodeQrcodeFile read the text of a qrcode png, marked png's watermark below the qrcode is cut off
+func decodeQrcodeFile(target string) (string, error) {
+	f, err := os.Open(target)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return "", err
+	}
+
+	bounds := img.Bounds()
+	if bounds.Dy() > bounds.Dx() {
+		if subImg, ok := img.(interface {
+			SubImage(r image.Rectangle) image.Image
+		}); ok {
+			img = subImg.SubImage(image.Rect(bounds.Min.X, bounds.Min.Y, bounds.Max.X, bounds.Min.Y+bounds.Dx()))
+		}
+	}
+
+	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return "", err
+	}
+	result, err := gozxingqrcode.NewQRCodeReader().Decode(bmp, nil)
+	if err != nil {
+		return "", err
+	}
+	return result.GetText(), nil
 }
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	log "github.com/sirupsen/logrus"
+)
+
+// importAccountCmd import geth keystore files and raw hex private key files,
+// re-encrypt them with two random passwords into a new version folder of ~/account
+func importAccountCmd(keystoreFiles, privateKeyFiles []string) {
+	if len(keystoreFiles) == 0 && len(privateKeyFiles) == 0 {
+		log.Fatalln("keystore or private key file is required")
+	}
+
+	privateKeys := []*ecdsa.PrivateKey{}
+	for _, file := range keystoreFiles {
+		privateKey, err := readImportKeystore(file)
+		if err != nil {
+			log.Fatalln(err.Error())
+		}
+		privateKeys = append(privateKeys, privateKey)
+	}
+	for _, file := range privateKeyFiles {
+		privateKey, err := readImportPrivateKey(file)
+		if err != nil {
+			log.Fatalln(err.Error())
+		}
+		privateKeys = append(privateKeys, privateKey)
+	}
+
+	accountPath, err := mkdirBySlice([]string{HomeDir(), "account"})
+	if err != nil {
+		log.Fatalln("Fail to create account directory")
+	}
+	timeDir := strings.Join([]string{"version_1", time.Now().Format("2006-01-02_15-04-05")}, "_")
+
+	addresses := []*csvAddress{}
+	for _, privateKey := range privateKeys {
+		address := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+		if _, err := accountDir(address); err == nil {
+			log.Warnln("Ignore:", address, "already in keystore")
+			continue
+		}
+		saveAccount(privateKey, &AccountMetaJSON{Imported: true}, *accountPath, timeDir)
+		addresses = append(addresses, &csvAddress{Address: address})
+	}
+	if len(addresses) > 0 {
+		export2CSV(addresses, *accountPath)
+		if err := writeBatchManifest(timeDir); err != nil {
+			log.Fatalln("write manifest error", err.Error())
+		}
+	}
+}
+
+func readImportKeystore(file string) (*ecdsa.PrivateKey, error) {
+	keyjson, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.New(strings.Join([]string{"read keystore error", err.Error()}, " "))
+	}
+
+	fmt.Println("Enter the password of keystore", file)
+	pwd, err := promptPwd()
+	if err != nil {
+		return nil, err
+	}
+	key, err := keystore.DecryptKey(keyjson, *pwd)
+	if err != nil {
+		return nil, errors.New(strings.Join([]string{"decrypt keystore", file, "error", err.Error()}, " "))
+	}
+	return key.PrivateKey, nil
+}
+
+func readImportPrivateKey(file string) (*ecdsa.PrivateKey, error) {
+	bKey, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.New(strings.Join([]string{"read private key error", err.Error()}, " "))
+	}
+	hexKey := strings.TrimPrefix(strings.TrimSpace(string(bKey)), "0x")
+	privateKey, err := crypto.HexToECDSA(hexKey)
+	if err != nil {
+		return nil, errors.New(strings.Join([]string{"invalid private key in", file, err.Error()}, " "))
+	}
+	return privateKey, nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// kdfProfile 密钥库加密参数
+type kdfProfile struct {
+	name    string
+	kdf     string
+	scryptN int
+	scryptP int
+	pbkdf2C int
+}
+
+// kdfProfiles built-in keystore KDF profiles, test is only for dev chains and integration tests
+var kdfProfiles = map[string]*kdfProfile{
+	"standard": {name: "standard", kdf: "scrypt", scryptN: keystore.StandardScryptN, scryptP: keystore.StandardScryptP},
+	"light":    {name: "light", kdf: "scrypt", scryptN: keystore.LightScryptN, scryptP: keystore.LightScryptP},
+	"test":     {name: "test", kdf: "scrypt", scryptN: 1 << 8, scryptP: 1},
+}
+
+// keystoreKDF profile of the keystores written by this run
+var keystoreKDF = kdfProfiles["standard"]
+
+// initKDFProfile pick the keystore KDF profile, flag takes precedence over configure
+func initKDFProfile(name string) error {
+	for profileName, profile := range config.KDFProfiles {
+		if _, ok := kdfProfiles[profileName]; ok {
+			return errors.New(strings.Join([]string{"could not redefine built-in kdf profile", profileName}, " "))
+		}
+		if err := profile.validate(); err != nil {
+			return err
+		}
+		kdfProfiles[profileName] = profile
+	}
+
+	if name == "" {
+		name = config.KDFProfile
+	}
+	if name == "" {
+		name = "standard"
+	}
+	profile, err := lookupKDFProfile(name)
+	if err != nil {
+		return err
+	}
+	keystoreKDF = profile
+	return nil
+}
+
+// lookupKDFProfile keystores written before profiles are standard
+func lookupKDFProfile(name string) (*kdfProfile, error) {
+	if name == "" {
+		name = "standard"
+	}
+	profile, ok := kdfProfiles[strings.ToLower(name)]
+	if !ok {
+		return nil, errors.New(strings.Join([]string{"unknown kdf profile:", name}, " "))
+	}
+	return profile, nil
+}
+
+func (p *kdfProfile) validate() error {
+	switch p.kdf {
+	case "scrypt":
+		if p.scryptN < 2 || p.scryptN&(p.scryptN-1) != 0 || p.scryptP < 1 {
+			return errors.New(strings.Join([]string{"kdf profile", p.name, "requires scrypt_n power of 2 and scrypt_p >= 1"}, " "))
+		}
+	case "pbkdf2":
+		if p.pbkdf2C < 1 {
+			return errors.New(strings.Join([]string{"kdf profile", p.name, "requires pbkdf2_c >= 1"}, " "))
+		}
+	default:
+		return errors.New(strings.Join([]string{"kdf profile", p.name, "only support kdf scrypt, pbkdf2"}, " "))
+	}
+	return nil
+}
+
+// memory bytes one keystore encryption needs, scrypt allocates 128 * r * N with r = 8
+func (p *kdfProfile) memory() uint64 {
+	if p.kdf == "scrypt" {
+		return 128 * 8 * uint64(p.scryptN)
+	}
+	return 0
+}
+
+func (p *kdfProfile) String() string {
+	if p.kdf == "pbkdf2" {
+		return strings.Join([]string{p.name, "pbkdf2", "c=" + strconv.Itoa(p.pbkdf2C)}, " ")
+	}
+	return strings.Join([]string{p.name, "scrypt", "n=" + strconv.Itoa(p.scryptN), "p=" + strconv.Itoa(p.scryptP)}, " ")
+}
+
+// encryptKey encrypt the key as Web3 Secret Storage v3 keystore with the profile's KDF
+func (p *kdfProfile) encryptKey(key *keystore.Key, auth string) ([]byte, error) {
+	if p.kdf == "scrypt" {
+		return keystore.EncryptKey(key, auth, p.scryptN, p.scryptP)
+	}
+	return encryptKeyPBKDF2(key, auth, p.pbkdf2C)
+}
+
+type pbkdf2KeyJSON struct {
+	Address string           `json:"address"`
+	Crypto  pbkdf2CryptoJSON `json:"crypto"`
+	ID      string           `json:"id"`
+	Version int              `json:"version"`
+}
+
+type pbkdf2CryptoJSON struct {
+	Cipher       string                 `json:"cipher"`
+	CipherText   string                 `json:"ciphertext"`
+	CipherParams map[string]string      `json:"cipherparams"`
+	KDF          string                 `json:"kdf"`
+	KDFParams    map[string]interface{} `json:"kdfparams"`
+	MAC          string                 `json:"mac"`
+}
+
+// encryptKeyPBKDF2 go-ethereum only encrypts with scrypt, keystore.DecryptKey reads pbkdf2 hmac-sha256
+func encryptKeyPBKDF2(key *keystore.Key, auth string, c int) ([]byte, error) {
+	salt := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	derivedKey := pbkdf2.Key([]byte(auth), salt, c, 32, sha256.New)
+
+	block, err := aes.NewCipher(derivedKey[:16])
+	if err != nil {
+		return nil, err
+	}
+	keyBytes := crypto.FromECDSA(key.PrivateKey)
+	cipherText := make([]byte, len(keyBytes))
+	cipher.NewCTR(block, iv).XORKeyStream(cipherText, keyBytes)
+	mac := crypto.Keccak256(derivedKey[16:32], cipherText)
+
+	return json.Marshal(&pbkdf2KeyJSON{
+		Address: hex.EncodeToString(key.Address[:]),
+		Crypto: pbkdf2CryptoJSON{
+			Cipher:       "aes-128-ctr",
+			CipherText:   hex.EncodeToString(cipherText),
+			CipherParams: map[string]string{"iv": hex.EncodeToString(iv)},
+			KDF:          "pbkdf2",
+			KDFParams: map[string]interface{}{
+				"c":     c,
+				"dklen": 32,
+				"prf":   "hmac-sha256",
+				"salt":  hex.EncodeToString(salt),
+			},
+			MAC: hex.EncodeToString(mac),
+		},
+		ID:      key.Id.String(),
+		Version: 3,
+	})
+}
+
+// minMainnetPBKDF2C pbkdf2 iterations floor of mainnet keys, the count geth and web3 use
+const minMainnetPBKDF2C = 262144
+
+// keystoreKDFJSON kdf and parameters of a keystore file
+type keystoreKDFJSON struct {
+	Crypto struct {
+		KDF       string                 `json:"kdf"`
+		KDFParams map[string]interface{} `json:"kdfparams"`
+	} `json:"crypto"`
+}
+
+// checkSignKDFProfile refuse to sign on mainnet chains with keys below the standard profile,
+// the kdf parameters are read from the keystore itself, not from meta.json
+func checkSignKDFProfile(address string) error {
+	if chain == nil || !chain.mainnet {
+		return nil
+	}
+	timeDir, err := accountDir(address)
+	if err != nil {
+		return err
+	}
+	keyjson, err := readKeyStore(address, strings.Join([]string{HomeDir(), "account", "keystore", *timeDir}, "/"))
+	if err != nil {
+		return errors.New(strings.Join([]string{"read keystore error", err.Error()}, " "))
+	}
+	var k keystoreKDFJSON
+	if err := json.Unmarshal(keyjson, &k); err != nil {
+		return errors.New(strings.Join([]string{"parse keystore", address, "error", err.Error()}, " "))
+	}
+
+	weak := errors.New(strings.Join([]string{address, "keystore kdf is weaker than the standard profile, refuse to sign on mainnet"}, " "))
+	param := func(name string) int {
+		// json numbers are float64
+		v, _ := k.Crypto.KDFParams[name].(float64)
+		return int(v)
+	}
+	switch k.Crypto.KDF {
+	case "scrypt":
+		if param("n") < keystore.StandardScryptN || param("p") < keystore.StandardScryptP {
+			return weak
+		}
+	case "pbkdf2":
+		if param("c") < minMainnetPBKDF2C {
+			return weak
+		}
+	default:
+		return errors.New(strings.Join([]string{address, "keystore has unknown kdf", k.Crypto.KDF}, " "))
+	}
+	return nil
+}
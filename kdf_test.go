@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+func testKey(t *testing.T) *keystore.Key {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := uuid.NewRandom()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &keystore.Key{Id: id, Address: crypto.PubkeyToAddress(privateKey.PublicKey), PrivateKey: privateKey}
+}
+
+func TestEncryptKeyPBKDF2RoundTrip(t *testing.T) {
+	key := testKey(t)
+	keyjson, err := encryptKeyPBKDF2(key, "first half second half", 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decrypted, err := keystore.DecryptKey(keyjson, "first half second half")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted.Address != key.Address {
+		t.Fatalf("decrypted address %s, want %s", decrypted.Address.Hex(), key.Address.Hex())
+	}
+	if !bytes.Equal(crypto.FromECDSA(decrypted.PrivateKey), crypto.FromECDSA(key.PrivateKey)) {
+		t.Fatal("decrypted private key differs")
+	}
+	if decrypted.Id != key.Id {
+		t.Fatalf("decrypted id %s, want %s", decrypted.Id, key.Id)
+	}
+
+	if _, err := keystore.DecryptKey(keyjson, "wrong password"); err == nil {
+		t.Fatal("expected error for wrong password")
+	}
+}
+
+// writeTestKeystore write the key under a temporary home as account/keystore/<batch>/<address>.json
+func writeTestKeystore(t *testing.T, home string, key *keystore.Key, profile *kdfProfile) string {
+	keyjson, err := profile.encryptKey(key, "auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ksPath := strings.Join([]string{home, "account", "keystore", "20240101000000"}, "/")
+	if err := os.MkdirAll(ksPath, 0700); err != nil {
+		t.Fatal(err)
+	}
+	address := key.Address.Hex()
+	if err := ioutil.WriteFile(strings.Join([]string{ksPath, strings.Join([]string{address, "json"}, ".")}, "/"), keyjson, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return address
+}
+
+func TestCheckSignKDFProfile(t *testing.T) {
+	home, err := ioutil.TempDir("", "kdf-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", home)
+	defer func(disable bool) { homedir.DisableCache = disable }(homedir.DisableCache)
+	homedir.DisableCache = true
+	defer func(c *chainProfile) { chain = c }(chain)
+
+	light := writeTestKeystore(t, home, testKey(t), kdfProfiles["light"])
+	weakPBKDF2 := writeTestKeystore(t, home, testKey(t), &kdfProfile{name: "weak", kdf: "pbkdf2", pbkdf2C: minMainnetPBKDF2C - 1})
+	pbkdf2Floor := writeTestKeystore(t, home, testKey(t), &kdfProfile{name: "floor", kdf: "pbkdf2", pbkdf2C: minMainnetPBKDF2C})
+
+	chain = chainProfiles["mainnet"]
+	for _, address := range []string{light, weakPBKDF2} {
+		if err := checkSignKDFProfile(address); err == nil || !strings.Contains(err.Error(), "weaker than the standard profile") {
+			t.Fatalf("%s: expected weak kdf error on mainnet, got %v", address, err)
+		}
+	}
+	if err := checkSignKDFProfile(pbkdf2Floor); err != nil {
+		t.Fatalf("pbkdf2 at the mainnet floor: %v", err)
+	}
+
+	// dev chains accept any profile
+	chain = chainProfiles["privatenet"]
+	if err := checkSignKDFProfile(light); err != nil {
+		t.Fatalf("light scrypt on privatenet: %v", err)
+	}
+}
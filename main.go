@@ -3,6 +3,8 @@ package main
 var (
 	config    *configure
 	etherscan *EtherScan
+	// version is recorded in batch manifest, set with -ldflags "-X main.version=..."
+	version = "dev"
 )
 
 func main() {
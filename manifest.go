@@ -0,0 +1,287 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// BatchManifest 批次清单，记录每个账户的派生路径、密钥库与二维码文件哈希
+type BatchManifest struct {
+	Batch       string `json:"batch"`
+	ToolVersion string `json:"tool_version"`
+	Created     string `json:"created"`
+	// Files meta.json and the randompwd.json halves of the batch, file to hash
+	Files    map[string]string  `json:"files,omitempty"`
+	Accounts []*ManifestAccount `json:"accounts"`
+}
+
+// ManifestAccount file hashes are hex sha256
+type ManifestAccount struct {
+	Address  string `json:"address"`
+	Seed     string `json:"seed,omitempty"`
+	PATH     string `json:"path,omitempty"`
+	Keystore string `json:"keystore"`
+	// KDFProfile profile name from meta.json, KDF and KDFParams read from the keystore itself
+	KDFProfile string                 `json:"kdf_profile,omitempty"`
+	KDF        string                 `json:"kdf"`
+	KDFParams  map[string]interface{} `json:"kdf_params"`
+	// Qrcodes mnemonic backup files of the seed account, file name to hash
+	Qrcodes map[string]string `json:"qrcodes,omitempty"`
+}
+
+func manifestFilePath(timeDir string) string {
+	return strings.Join([]string{HomeDir(), "account", "keystore", timeDir, "manifest.json"}, "/")
+}
+
+// writeBatchManifest describe the batch as a whole in account/keystore/<batch>/manifest.json
+func writeBatchManifest(timeDir string) error {
+	manifest := &BatchManifest{
+		Batch:       timeDir,
+		ToolVersion: version,
+		Created:     time.Now().Format(time.RFC3339),
+		Accounts:    []*ManifestAccount{},
+	}
+	batchFiles, err := hashBatchFiles(timeDir)
+	if err != nil {
+		return err
+	}
+	manifest.Files = batchFiles
+
+	files, err := ioutil.ReadDir(strings.Join([]string{HomeDir(), "account", "keystore", timeDir}, "/"))
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		address := strings.TrimSuffix(f.Name(), ".json")
+		if !strings.HasPrefix(address, "0x") {
+			continue
+		}
+		account, err := manifestAccount(address, timeDir)
+		if err != nil {
+			return err
+		}
+		manifest.Accounts = append(manifest.Accounts, account)
+	}
+
+	bManifest, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(manifestFilePath(timeDir), bManifest, 0600); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		"Write manifest": manifestFilePath(timeDir),
+		"Accounts":       len(manifest.Accounts),
+	}).Info("")
+	return nil
+}
+
+func readBatchManifest(timeDir string) (*BatchManifest, error) {
+	data, err := ioutil.ReadFile(manifestFilePath(timeDir))
+	if err != nil {
+		return nil, err
+	}
+	var manifest BatchManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, errors.New(strings.Join([]string{"invalid manifest", err.Error()}, " "))
+	}
+	return &manifest, nil
+}
+
+// manifestAccount hash the keystore and the mnemonic backup files of the account as they are on disk
+func manifestAccount(address, timeDir string) (*ManifestAccount, error) {
+	keystorefile := strings.Join([]string{HomeDir(), "account", "keystore", timeDir, strings.Join([]string{address, "json"}, ".")}, "/")
+	keyjson, err := ioutil.ReadFile(keystorefile)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(keyjson)
+
+	var ks keystoreKDFJSON
+	if err := json.Unmarshal(keyjson, &ks); err != nil {
+		return nil, errors.New(strings.Join([]string{"invalid keystore", keystorefile, err.Error()}, " "))
+	}
+
+	account := &ManifestAccount{
+		Address:   address,
+		Keystore:  hex.EncodeToString(sum[:]),
+		KDF:       ks.Crypto.KDF,
+		KDFParams: ks.Crypto.KDFParams,
+	}
+	if meta, err := readAccountMeta(address, timeDir); err == nil {
+		account.Seed, account.PATH, account.KDFProfile = meta.Seed, meta.PATH, meta.KDF
+	}
+
+	// the mnemonic backup belongs to the seed account, accounts before meta.json own their backup
+	if account.Seed == "" || strings.EqualFold(account.Seed, address) {
+		qrcodes, err := hashDirFiles(strings.Join([]string{HomeDir(), "account", "mnemonic_qrcode", timeDir, address}, "/"))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		account.Qrcodes = qrcodes
+	}
+	return account, nil
+}
+
+// hashBatchFiles hash meta.json and both randompwd.json of the batch, meta.json carries the dual_control,
+// kdf and imported flags. A file that does not exist is left out, dual control batches have no randompwd.json
+func hashBatchFiles(timeDir string) (map[string]string, error) {
+	files := map[string]string{
+		"meta.json": strings.Join([]string{HomeDir(), "account", "keystore", timeDir, "meta.json"}, "/"),
+	}
+	for _, pwdType := range []string{"random_pwd_first", "random_pwd_second"} {
+		pwdFile, err := pwdFilePath(pwdType, timeDir)
+		if err != nil {
+			return nil, err
+		}
+		files[strings.Join([]string{pwdType, "randompwd.json"}, "/")] = *pwdFile
+	}
+
+	hashes := map[string]string{}
+	for name, file := range files {
+		hash, err := sha256File(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		hashes[name] = hash
+	}
+	return hashes, nil
+}
+
+func hashDirFiles(dir string) (map[string]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	hashes := map[string]string{}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		hash, err := sha256File(strings.Join([]string{dir, f.Name()}, "/"))
+		if err != nil {
+			return nil, err
+		}
+		hashes[f.Name()] = hash
+	}
+	return hashes, nil
+}
+
+func sha256File(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifyManifestAccount compare the files on disk with the manifest entry, return every difference
+func verifyManifestAccount(entry *ManifestAccount, timeDir string) []string {
+	problems := []string{}
+	current, err := manifestAccount(entry.Address, timeDir)
+	if err != nil {
+		return append(problems, strings.Join([]string{"manifest check error:", err.Error()}, " "))
+	}
+
+	if current.Keystore != entry.Keystore {
+		problems = append(problems, "keystore hash differs from manifest")
+	}
+	if current.PATH != entry.PATH {
+		problems = append(problems, strings.Join([]string{"derivation path differs from manifest:", current.PATH}, " "))
+	}
+
+	return append(problems, compareFileHashes("qrcode", entry.Qrcodes, current.Qrcodes)...)
+}
+
+// verifyManifestBatch compare meta.json and randompwd.json of the batch with the manifest, manifests written
+// before the files were listed have nothing to compare
+func verifyManifestBatch(manifest *BatchManifest, timeDir string) []string {
+	if manifest.Files == nil {
+		return []string{}
+	}
+	current, err := hashBatchFiles(timeDir)
+	if err != nil {
+		return []string{strings.Join([]string{"manifest check error:", err.Error()}, " ")}
+	}
+	return compareFileHashes("batch", manifest.Files, current)
+}
+
+// compareFileHashes report files missing, changed or added against the manifest in name order
+func compareFileHashes(kind string, listed, current map[string]string) []string {
+	problems := []string{}
+	names := []string{}
+	for name := range listed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		hash, ok := current[name]
+		switch {
+		case !ok:
+			problems = append(problems, strings.Join([]string{kind, "file missing:", name}, " "))
+		case hash != listed[name]:
+			problems = append(problems, strings.Join([]string{kind, "file hash differs from manifest:", name}, " "))
+		}
+	}
+	added := []string{}
+	for name := range current {
+		if _, ok := listed[name]; !ok {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(added)
+	for _, name := range added {
+		problems = append(problems, strings.Join([]string{kind, "file not in manifest:", name}, " "))
+	}
+	return problems
+}
+
+// updateManifestKeystores refresh the keystore hash of re-encrypted accounts and the hashes of the rewritten
+// randompwd.json, other entries are kept as they are
+func updateManifestKeystores(timeDir string, addresses []string) error {
+	manifest, err := readBatchManifest(timeDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, address := range addresses {
+		for _, entry := range manifest.Accounts {
+			if !strings.EqualFold(entry.Address, address) {
+				continue
+			}
+			current, err := manifestAccount(entry.Address, timeDir)
+			if err != nil {
+				return err
+			}
+			entry.Keystore, entry.KDF, entry.KDFParams = current.Keystore, current.KDF, current.KDFParams
+		}
+	}
+
+	bManifest, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(manifestFilePath(timeDir), bManifest, 0600)
+}
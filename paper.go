@@ -0,0 +1,205 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	qrcode "github.com/skip2/go-qrcode"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/vgpdf"
+	"gonum.org/v1/plot/vg/vgsvg"
+)
+
+// A4 paper size
+const (
+	paperWidth  = 210 * vg.Millimeter
+	paperHeight = 297 * vg.Millimeter
+	paperMargin = 15 * vg.Millimeter
+	paperQRSize = 80 * vg.Millimeter
+	// paperQRPerPage two rows of two qrcode fit below the header
+	paperQRPerPage = 4
+)
+
+// paperCanvas vgpdf and vgsvg canvas
+type paperCanvas interface {
+	vg.Canvas
+	WriteTo(w io.Writer) (int64, error)
+}
+
+// paperQrcode qrcode with its caption on the paper wallet
+type paperQrcode struct {
+	label string
+	text  string
+}
+
+// paperPage one printable document, split pages are written to separate files for separate storage
+type paperPage struct {
+	name     string
+	qrcodes  []paperQrcode
+	subtitle string
+}
+
+// savePaperWallet write printable paper wallet of the mnemonic backup: address qrcode, encrypted mnemonic qrcode
+// and key qrcode (or shares) with derivation path, batch id, creation time and checksum
+func savePaperWallet(m *MnemonicJSON, payload string, keyTexts []string, dir, timeDir string) error {
+	if paperFormat != "pdf" && paperFormat != "svg" {
+		return errors.New("Only support paper format pdf, svg")
+	}
+
+	paperPath, err := mkdirBySlice([]string{dir, "mnemonic_qrcode", timeDir, m.Address})
+	if err != nil {
+		return errors.New(strings.Join([]string{"Could not create directory", err.Error()}, " "))
+	}
+
+	h := sha256.New()
+	h.Write([]byte(m.Address))
+	h.Write([]byte(payload))
+	for _, keyText := range keyTexts {
+		h.Write([]byte(keyText))
+	}
+	checksum := hex.EncodeToString(h.Sum(nil))[:16]
+
+	keyQrcodes := []paperQrcode{}
+	for i, keyText := range keyTexts {
+		label := "AES key"
+		if len(keyTexts) > 1 {
+			label = strings.Join([]string{"AES key share", strconv.Itoa(i + 1), "of", strconv.Itoa(len(keyTexts))}, " ")
+		}
+		keyQrcodes = append(keyQrcodes, paperQrcode{label, keyText})
+	}
+	addressQrcode := paperQrcode{"Ethereum address", m.Address}
+	mnemonicQrcode := paperQrcode{"Encrypted mnemonic", payload}
+
+	pages := []paperPage{}
+	if paperSplit {
+		pages = append(pages, paperPage{"paper_mnemonic", []paperQrcode{addressQrcode, mnemonicQrcode}, "encrypted mnemonic, store apart from the key"})
+		for i, keyQrcode := range keyQrcodes {
+			name := "paper_key"
+			if len(keyQrcodes) > 1 {
+				name = strings.Join([]string{"paper_key_share", strconv.Itoa(i + 1)}, "_")
+			}
+			pages = append(pages, paperPage{name, []paperQrcode{addressQrcode, keyQrcode}, "key, store apart from the encrypted mnemonic"})
+		}
+	} else {
+		// key shares beyond the first page go on continuation pages
+		qrcodes := append([]paperQrcode{addressQrcode, mnemonicQrcode}, keyQrcodes...)
+		pageCount := (len(qrcodes) + paperQRPerPage - 1) / paperQRPerPage
+		for i := 0; i < pageCount; i++ {
+			end := (i + 1) * paperQRPerPage
+			if end > len(qrcodes) {
+				end = len(qrcodes)
+			}
+			name, subtitle := "paper", "keep secret"
+			if pageCount > 1 {
+				subtitle = strings.Join([]string{"keep secret, page", strconv.Itoa(i + 1), "of", strconv.Itoa(pageCount)}, " ")
+			}
+			if i > 0 {
+				name = strings.Join([]string{"paper", strconv.Itoa(i + 1)}, "_")
+			}
+			pages = append(pages, paperPage{name, qrcodes[i*paperQRPerPage : end], subtitle})
+		}
+	}
+
+	lines := []string{
+		strings.Join([]string{"Address:", m.Address}, " "),
+		strings.Join([]string{"Derivation path:", m.PATH}, " "),
+		strings.Join([]string{"Accounts:", strconv.Itoa(m.Count)}, " "),
+		strings.Join([]string{"Batch:", timeDir}, " "),
+		strings.Join([]string{"Created:", time.Now().Format("2006-01-02 15:04:05")}, " "),
+		strings.Join([]string{"Checksum:", checksum}, " "),
+	}
+	if m.Passphrase {
+		lines = append(lines, "BIP39 passphrase required")
+	}
+
+	for _, page := range pages {
+		fileName := strings.Join([]string{strings.Join([]string{m.Address, page.name}, "_"), paperFormat}, ".")
+		if err := writePaperPage(strings.Join([]string{*paperPath, fileName}, "/"), page, lines); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writePaperPage(file string, page paperPage, lines []string) error {
+	var c paperCanvas
+	if paperFormat == "pdf" {
+		c = vgpdf.New(paperWidth, paperHeight)
+	} else {
+		c = vgsvg.New(paperWidth, paperHeight)
+	}
+
+	titleFont, err := vg.MakeFont("Courier-Bold", 14)
+	if err != nil {
+		return err
+	}
+	textFont, err := vg.MakeFont("Courier", 9)
+	if err != nil {
+		return err
+	}
+
+	c.SetColor(color.Black)
+	y := paperHeight - paperMargin
+	c.FillString(titleFont, vg.Point{X: paperMargin, Y: y}, "Ethereum Cold Wallet")
+	y -= 6 * vg.Millimeter
+	c.FillString(textFont, vg.Point{X: paperMargin, Y: y}, page.subtitle)
+	for _, line := range lines {
+		y -= 5 * vg.Millimeter
+		c.FillString(textFont, vg.Point{X: paperMargin, Y: y}, line)
+	}
+	y -= 5 * vg.Millimeter
+
+	// two qrcode per row
+	for i, qr := range page.qrcodes {
+		if i%2 == 0 {
+			y -= paperQRSize + 8*vg.Millimeter
+		}
+		x := paperMargin
+		if i%2 == 1 {
+			x = paperWidth - paperMargin - paperQRSize
+		}
+		if y < paperMargin {
+			return errors.New("too many qrcode for one paper page, use split")
+		}
+
+		img, err := qrcodeImage(qr.text)
+		if err != nil {
+			return err
+		}
+		c.DrawImage(vg.Rectangle{
+			Min: vg.Point{X: x, Y: y},
+			Max: vg.Point{X: x + paperQRSize, Y: y + paperQRSize},
+		}, img)
+		c.FillString(textFont, vg.Point{X: x, Y: y - 4*vg.Millimeter}, qr.label)
+	}
+
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := c.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func qrcodeImage(text string) (image.Image, error) {
+	qr, err := qrcode.New(text, qrcode.Highest)
+	if err != nil {
+		// long payload doesn't fit the highest recovery level
+		qr, err = qrcode.New(text, qrcode.Medium)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return qr.Image(1024), nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// mnemonic qrcode payload formats:
+//
+//	version 1: base64(AES-CBC(MnemonicJSON, iv = key[:16])) + base64url(sha256(ciphertext))
+//	version 2: "ECW2:" + base64(header | AES-256-GCM(MnemonicJSON)), the header is authenticated as additional data
+//	  header: version(1) | kdf(1) | log2(N)(1) | r(1) | p(1) | salt(16) | nonce(12)
+const (
+	payloadV2Prefix = "ECW2:"
+
+	payloadVersion2  byte = 2
+	payloadKDFScrypt byte = 1
+
+	payloadScryptLogN = 15
+	payloadScryptR    = 8
+	payloadScryptP    = 1
+	payloadSaltLen    = 16
+	payloadNonceLen   = 12
+	payloadHeaderLen  = 5 + payloadSaltLen + payloadNonceLen
+)
+
+// encryptMnemonicPayload encrypt MnemonicJSON to version 2 qrcode payload, the AES key is derived from key by scrypt
+func encryptMnemonicPayload(bMnemonicJSON []byte, key string) (string, error) {
+	header := make([]byte, payloadHeaderLen)
+	header[0] = payloadVersion2
+	header[1] = payloadKDFScrypt
+	header[2] = payloadScryptLogN
+	header[3] = payloadScryptR
+	header[4] = payloadScryptP
+	if _, err := rand.Read(header[5:]); err != nil {
+		return "", err
+	}
+	salt := header[5 : 5+payloadSaltLen]
+	nonce := header[5+payloadSaltLen:]
+
+	aead, err := payloadAEAD(key, salt, payloadScryptLogN, payloadScryptR, payloadScryptP)
+	if err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(append([]byte{}, header...), nonce, bMnemonicJSON, header)
+	return strings.Join([]string{payloadV2Prefix, base64.StdEncoding.EncodeToString(sealed)}, ""), nil
+}
+
+// decryptMnemonicPayload decrypt the mnemonic qrcode payload of version 1 or version 2
+func decryptMnemonicPayload(payload, key string) (*MnemonicJSON, error) {
+	var (
+		bMnemonicJSON []byte
+		err           error
+	)
+	if strings.HasPrefix(payload, payloadV2Prefix) {
+		bMnemonicJSON, err = decryptMnemonicPayloadV2(strings.TrimPrefix(payload, payloadV2Prefix), key)
+	} else {
+		bMnemonicJSON, err = decryptMnemonicPayloadV1(payload, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var m MnemonicJSON
+	if err := json.Unmarshal(bMnemonicJSON, &m); err != nil {
+		return nil, errors.New("decrypt mnemonic error, wrong key?")
+	}
+	return &m, nil
+}
+
+func decryptMnemonicPayloadV2(payload, key string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, errors.New(strings.Join([]string{"decode mnemonic qrcode payload error", err.Error()}, " "))
+	}
+	if len(sealed) < payloadHeaderLen {
+		return nil, errors.New("mnemonic qrcode payload too short")
+	}
+
+	header := sealed[:payloadHeaderLen]
+	if header[0] != payloadVersion2 {
+		return nil, errors.New(strings.Join([]string{"unsupported mnemonic qrcode payload version", strconv.Itoa(int(header[0]))}, " "))
+	}
+	if header[1] != payloadKDFScrypt {
+		return nil, errors.New(strings.Join([]string{"unsupported mnemonic qrcode payload kdf", strconv.Itoa(int(header[1]))}, " "))
+	}
+	salt := header[5 : 5+payloadSaltLen]
+	nonce := header[5+payloadSaltLen:]
+
+	aead, err := payloadAEAD(key, salt, header[2], header[3], header[4])
+	if err != nil {
+		return nil, err
+	}
+	bMnemonicJSON, err := aead.Open(nil, nonce, sealed[payloadHeaderLen:], header)
+	if err != nil {
+		return nil, errors.New("mnemonic qrcode payload authentication failed: tampered payload or wrong key")
+	}
+	return bMnemonicJSON, nil
+}
+
+func payloadAEAD(key string, salt []byte, logN, r, p byte) (cipher.AEAD, error) {
+	if logN < 10 || logN > 20 || r == 0 || p == 0 {
+		return nil, errors.New("invalid mnemonic qrcode payload kdf parameters")
+	}
+	derivedKey, err := scrypt.Key([]byte(key), salt, 1<<logN, int(r), int(p), 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(derivedKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCMWithNonceSize(block, payloadNonceLen)
+}
+
+// decryptMnemonicPayloadV1 check the sha256 suffix and decrypt the AES-CBC ciphertext written by version 1
+func decryptMnemonicPayloadV1(payload, key string) ([]byte, error) {
+	// base64 url encoding of sha256 sum is always 44 characters
+	shaLen := base64.URLEncoding.EncodedLen(sha256.Size)
+	if len(payload) <= shaLen {
+		return nil, errors.New("mnemonic qrcode payload too short")
+	}
+	mnemonicScryptedStr, mnemonicSha := payload[:len(payload)-shaLen], payload[len(payload)-shaLen:]
+
+	mNemonicCrypted, err := base64.StdEncoding.DecodeString(mnemonicScryptedStr)
+	if err != nil {
+		return nil, errors.New(strings.Join([]string{"decode mnemonic qrcode payload error", err.Error()}, " "))
+	}
+
+	h := sha256.New()
+	h.Write(mNemonicCrypted)
+	if base64.URLEncoding.EncodeToString(h.Sum(nil)) != mnemonicSha {
+		return nil, errors.New("mnemonic qrcode sha256 checksum mismatch")
+	}
+
+	bMnemonicJSON, err := AesDecrypt(mNemonicCrypted, []byte(key))
+	if err != nil {
+		return nil, errors.New(strings.Join([]string{"decrypt mnemonic error", err.Error()}, " "))
+	}
+	return bMnemonicJSON, nil
+}
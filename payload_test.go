@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const (
+	payloadTestKey      = "0123456789abcdefghijklmnopqrstuv"
+	payloadTestMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	payloadTestAddress  = "0x9858EfFD232B4033E47d90003D41EC34EcaEda94"
+	// payloadTestV1 written by version 1: base64(AES-CBC(MnemonicJSON)) + base64url(sha256(ciphertext))
+	payloadTestV1 = "aru7joMzmvOmijINN62hdKx+Zg6PlDI/P2dJCI1PJTj9XoRG+ur1+cd6d5VwL7ToLL9tTtltfeFtClI0qUR6gqeLiIBowLNt3ITqTQgP1GNLFTHRvR1GPqcrfs1ik0HZ7KLBDOUYRseJQTDKeqDx+qB+jABmQqSTUe+2dukspJe5FiejrUeINkxwRNJgrzeFRQeUoSzqNaDCRENbeFLXwEN+6UDskdIVKA97Bo3DanWMuKbP+Ve1+OV1TXkx6JGib1QAy_b8v5YIt-5xdHSjcV2qp9FE29cbG5G7ijDp1I8="
+)
+
+func testPayloadV2(t *testing.T) []byte {
+	bMnemonicJSON, err := json.Marshal(&MnemonicJSON{Address: payloadTestAddress, Mnemonic: payloadTestMnemonic, PATH: "m/44H/60H/0H/0/0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := encryptMnemonicPayload(bMnemonicJSON, payloadTestKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(payload, payloadV2Prefix) {
+		t.Fatalf("payload %q has no %s prefix", payload, payloadV2Prefix)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(payload, payloadV2Prefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sealed
+}
+
+func encodePayloadV2(sealed []byte) string {
+	return strings.Join([]string{payloadV2Prefix, base64.StdEncoding.EncodeToString(sealed)}, "")
+}
+
+func TestMnemonicPayloadV2RoundTrip(t *testing.T) {
+	sealed := testPayloadV2(t)
+	m, err := decryptMnemonicPayload(encodePayloadV2(sealed), payloadTestKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Mnemonic != payloadTestMnemonic || m.Address != payloadTestAddress {
+		t.Fatalf("decrypted %+v", m)
+	}
+
+	if _, err := decryptMnemonicPayload(encodePayloadV2(sealed), "wrong key wrong key wrong key 00"); err == nil {
+		t.Fatal("expected error for wrong key")
+	}
+}
+
+func TestMnemonicPayloadV2Tampered(t *testing.T) {
+	sealed := testPayloadV2(t)
+
+	// a bit of the ciphertext, the salt in the header and the tag
+	for _, i := range []int{payloadHeaderLen + 3, 5, len(sealed) - 1} {
+		tampered := append([]byte{}, sealed...)
+		tampered[i] ^= 0x01
+		_, err := decryptMnemonicPayload(encodePayloadV2(tampered), payloadTestKey)
+		if err == nil || !strings.Contains(err.Error(), "authentication failed") {
+			t.Fatalf("byte %d flipped: expected authentication error, got %v", i, err)
+		}
+	}
+}
+
+func TestMnemonicPayloadV2KDFHeader(t *testing.T) {
+	sealed := testPayloadV2(t)
+	for _, logN := range []byte{0, 9, 21, 63} {
+		tampered := append([]byte{}, sealed...)
+		tampered[2] = logN
+		_, err := decryptMnemonicPayload(encodePayloadV2(tampered), payloadTestKey)
+		if err == nil || !strings.Contains(err.Error(), "kdf parameters") {
+			t.Fatalf("log2(N) %d: expected kdf parameters error, got %v", logN, err)
+		}
+	}
+
+	tampered := append([]byte{}, sealed...)
+	tampered[0] = 3
+	if _, err := decryptMnemonicPayload(encodePayloadV2(tampered), payloadTestKey); err == nil {
+		t.Fatal("expected error for unsupported version")
+	}
+	if _, err := decryptMnemonicPayload(encodePayloadV2(sealed[:payloadHeaderLen-1]), payloadTestKey); err == nil {
+		t.Fatal("expected error for truncated payload")
+	}
+}
+
+func TestMnemonicPayloadV1(t *testing.T) {
+	m, err := decryptMnemonicPayload(payloadTestV1, payloadTestKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Mnemonic != payloadTestMnemonic || m.Address != payloadTestAddress || m.PATH != "m/44H/60H/0H/0/0" {
+		t.Fatalf("decrypted %+v", m)
+	}
+
+	// the sha256 suffix covers the ciphertext
+	tampered := strings.Join([]string{"b", payloadTestV1[1:]}, "")
+	if _, err := decryptMnemonicPayload(tampered, payloadTestKey); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Fatalf("expected checksum error, got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	log "github.com/sirupsen/logrus"
+	bip39 "github.com/tyler-smith/go-bip39"
+)
+
+func recoverAccountCmd(mnemonicQrcode, keyQrcode, mnemonicData, keyData string, shareQrcodes, shareData []string, saveKs bool) {
+	payload, err := qrcodeDataOrFile(mnemonicData, mnemonicQrcode)
+	if err != nil {
+		log.Fatalln("read mnemonic qrcode error", err.Error())
+	}
+
+	var key string
+	if len(shareQrcodes) > 0 || len(shareData) > 0 {
+		key, err = combineShareQrcodes(shareQrcodes, shareData)
+	} else {
+		key, err = qrcodeDataOrFile(keyData, keyQrcode)
+	}
+	if err != nil {
+		log.Fatalln("read key qrcode error", err.Error())
+	}
+
+	m, err := decryptMnemonicPayload(payload, key)
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+
+	// backups before word list selection are always english
+	language := m.Language
+	if language == "" {
+		language = "english"
+	}
+	if err := setMnemonicLanguage(language); err != nil {
+		log.Fatalln(err.Error())
+	}
+	if !bip39.IsMnemonicValid(m.Mnemonic) {
+		log.Fatalln("invalid mnemonic for word list", language)
+	}
+
+	var passphrase string
+	if m.Passphrase {
+		fmt.Println("The backup requires BIP39 passphrase")
+		pwd, err := promptPwd()
+		if err != nil {
+			log.Fatalln(err.Error())
+		}
+		passphrase = *pwd
+	}
+
+	accounts, err := recoverAccounts(m, passphrase)
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+
+	var (
+		accountDir *string
+		timeDir    string
+	)
+	if saveKs {
+		accountDir, err = mkdirBySlice([]string{HomeDir(), "account"})
+		if err != nil {
+			log.Fatalln("Fail to create account directory")
+		}
+		timeDir = strings.Join([]string{"version_1", time.Now().Format("2006-01-02_15-04-05")}, "_")
+	}
+
+	for _, account := range accounts {
+		if saveKs {
+			saveAccount(account.privateKey, &AccountMetaJSON{
+				Seed:       m.Address,
+				Index:      account.index,
+				PATH:       account.path,
+				Passphrase: m.Passphrase,
+			}, *accountDir, timeDir)
+			continue
+		}
+		log.WithFields(log.Fields{
+			"Recover Ethereum account": account.address,
+			"Derivation path":          account.path,
+			"Time:":                    time.Now().Format("Mon Jan _2 15:04:05 2006"),
+		}).Info("")
+	}
+	if saveKs {
+		if err := writeBatchManifest(timeDir); err != nil {
+			log.Fatalln("write manifest error", err.Error())
+		}
+	}
+}
+
+func qrcodeDataOrFile(data, file string) (string, error) {
+	if data != "" {
+		return data, nil
+	}
+	if file == "" {
+		return "", errors.New("qrcode file or data is required")
+	}
+	return decodeQrcodeFile(file)
+}
+
+// combineShareQrcodes restore the AES decrypt key from shamir share qrcode files and decoded texts
+func combineShareQrcodes(shareQrcodes, shareData []string) (string, error) {
+	texts := append([]string{}, shareData...)
+	for _, file := range shareQrcodes {
+		text, err := decodeQrcodeFile(file)
+		if err != nil {
+			return "", errors.New(strings.Join([]string{"decode share qrcode", file, "error", err.Error()}, " "))
+		}
+		texts = append(texts, text)
+	}
+
+	key, err := combineShareTexts(texts)
+	if err != nil {
+		return "", err
+	}
+	return string(key), nil
+}
+
+// recoverAccounts re-derive the accounts of the backup and confirm the address matches
+func recoverAccounts(m *MnemonicJSON, passphrase string) ([]*derivedAccount, error) {
+	// backups before derivation templates only record the path actually used
+	template := m.Template
+	if template == "" {
+		template = m.PATH
+	}
+
+	indexes := m.Indexes
+	if len(indexes) == 0 {
+		count := m.Count
+		if count < 1 {
+			count = 1
+		}
+		for index := 0; index < count; index++ {
+			indexes = append(indexes, uint32(index))
+		}
+	}
+
+	masterKey, err := hdMasterKey(m.Mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := []*derivedAccount{}
+	for i, index := range indexes {
+		privateKey, path, err := deriveHDKey(masterKey, template, index)
+		if err != nil {
+			return nil, err
+		}
+		address := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+		if i == 0 && !strings.EqualFold(address, m.Address) {
+			return nil, errors.New(strings.Join([]string{"recovered address", address, "not match backup address", m.Address}, " "))
+		}
+		accounts = append(accounts, &derivedAccount{privateKey, address, index, *path})
+	}
+	return accounts, nil
+}
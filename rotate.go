@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// rotateKeystorePwdCmd re-encrypt keystores of a batch or addresses with new random passwords
+func rotateKeystorePwdCmd(batch string, addresses []string) {
+	targets := map[string][]string{}
+	switch {
+	case batch != "":
+		files, err := ioutil.ReadDir(strings.Join([]string{HomeDir(), "account", "keystore", batch}, "/"))
+		if err != nil {
+			log.Fatalln("Get batch directory error", err.Error())
+		}
+		for _, f := range files {
+			if address := strings.TrimSuffix(f.Name(), ".json"); strings.HasPrefix(address, "0x") {
+				targets[batch] = append(targets[batch], address)
+			}
+		}
+	case len(addresses) > 0:
+		for _, address := range addresses {
+			timeDir, err := accountDir(address)
+			if err != nil {
+				log.Fatalln(address, err.Error())
+			}
+			targets[*timeDir] = append(targets[*timeDir], address)
+		}
+	default:
+		log.Fatalln("batch or address is required")
+	}
+
+	for timeDir, batchAddresses := range targets {
+		if err := rotateBatchPasswords(timeDir, batchAddresses); err != nil {
+			log.Fatalln("rotate passwords of", timeDir, "error", err.Error())
+		}
+		log.Infoln("rotated passwords of", len(batchAddresses), "accounts in", timeDir)
+	}
+}
+
+// rotateBatchPasswords back up the old set, write new keystores and randompwd.json files,
+// restore the backup if verification fails and remove it once verification passes
+func rotateBatchPasswords(timeDir string, addresses []string) error {
+	ksPath := strings.Join([]string{HomeDir(), "account", "keystore", timeDir}, "/")
+
+	// check every account and re-encrypt in memory before the old set is copied anywhere
+	profiles := map[string]*kdfProfile{}
+	for _, address := range addresses {
+		profile := kdfProfiles["standard"]
+		if meta, err := readAccountMeta(address, timeDir); err == nil {
+			if meta.DualControl {
+				return errors.New(strings.Join([]string{address, "is a dual control account, its password halves are not stored"}, " "))
+			}
+			// keep the KDF profile the keystore was written with
+			if profile, err = lookupKDFProfile(meta.KDF); err != nil {
+				return err
+			}
+		}
+		profiles[address] = profile
+	}
+
+	firstPwds, err := readPwdEntries("random_pwd_first", timeDir)
+	if err != nil {
+		return err
+	}
+	secondPwds, err := readPwdEntries("random_pwd_second", timeDir)
+	if err != nil {
+		return err
+	}
+
+	keyjsons := map[string][]byte{}
+	for _, address := range addresses {
+		key, err := decodeKS2KeyInDir(address, timeDir)
+		if err != nil {
+			return errors.New(strings.Join([]string{"decode keystore", address, "error", err.Error()}, " "))
+		}
+
+		randomPwdFirst, err := secret.generate(firstPwdLength)
+		if err != nil {
+			return err
+		}
+		randomPwdSecond, err := secret.generate(secondPwdLength)
+		if err != nil {
+			return err
+		}
+
+		keyjson, err := profiles[address].encryptKey(key, accountAuth(randomPwdFirst, randomPwdSecond))
+		if err != nil {
+			return err
+		}
+		keyjsons[address] = keyjson
+		firstPwds = replacePwdEntry(firstPwds, address, randomPwdFirst)
+		secondPwds = replacePwdEntry(secondPwds, address, randomPwdSecond)
+	}
+
+	// each password half is backed up on its own medium, never next to the keystores
+	stamp := strings.Join([]string{timeDir, time.Now().Format("2006-01-02_15-04-05")}, "_")
+	backupPaths := map[string]string{}
+	backupDirs := []string{}
+	// discard remove a backup which is not needed, the old plaintext passwords must not stay on the media
+	discard := func(cause error) error {
+		for _, backupDir := range backupDirs {
+			if err := removeAll(backupDir); err != nil {
+				message := []string{"remove backup", backupDir, "error", err.Error()}
+				if cause != nil {
+					message = append([]string{cause.Error(), "and"}, message...)
+				}
+				return errors.New(strings.Join(message, " "))
+			}
+		}
+		return cause
+	}
+	for _, name := range []string{"keystore", "random_pwd_first", "random_pwd_second"} {
+		root := strings.Join([]string{HomeDir(), "account"}, "/")
+		if name != "keystore" {
+			root = pwdRoot(name)
+		}
+		backupPath, err := mkdirBySlice([]string{root, "rotation_backup", stamp})
+		if err != nil {
+			return discard(errors.New(strings.Join([]string{"Could not create directory", err.Error()}, " ")))
+		}
+		backupPaths[name] = *backupPath
+		// default roots share one directory
+		if !Contains(backupDirs, *backupPath) {
+			backupDirs = append(backupDirs, *backupPath)
+		}
+	}
+
+	backups := map[string]string{}
+	for _, pwdType := range []string{"random_pwd_first", "random_pwd_second"} {
+		pwdFile, err := pwdFilePath(pwdType, timeDir)
+		if err != nil {
+			return discard(err)
+		}
+		backups[*pwdFile] = strings.Join([]string{backupPaths[pwdType], pwdType + ".json"}, "/")
+	}
+	for _, address := range addresses {
+		keystorefile := strings.Join([]string{ksPath, strings.Join([]string{address, "json"}, ".")}, "/")
+		backups[keystorefile] = strings.Join([]string{backupPaths["keystore"], strings.Join([]string{address, "json"}, ".")}, "/")
+	}
+	for src, dst := range backups {
+		if err := copyFile(src, dst); err != nil {
+			return discard(errors.New(strings.Join([]string{"backup", src, "error", err.Error()}, " ")))
+		}
+	}
+
+	restore := func(cause error) error {
+		for dst, src := range backups {
+			if err := copyFile(src, dst); err != nil {
+				return errors.New(strings.Join([]string{cause.Error(), "and restore", dst, "error", err.Error(), "backup kept in", strings.Join(backupDirs, ", ")}, " "))
+			}
+		}
+		return errors.New(strings.Join([]string{cause.Error(), "old passwords restored, backup kept in", strings.Join(backupDirs, ", ")}, " "))
+	}
+
+	if err := writePwdEntries("random_pwd_first", timeDir, firstPwds); err != nil {
+		return restore(err)
+	}
+	if err := writePwdEntries("random_pwd_second", timeDir, secondPwds); err != nil {
+		return restore(err)
+	}
+	for address, keyjson := range keyjsons {
+		keystorefile := strings.Join([]string{ksPath, strings.Join([]string{address, "json"}, ".")}, "/")
+		if err := writeFileAtomic(keystorefile, keyjson, 0600); err != nil {
+			return restore(err)
+		}
+	}
+
+	for _, address := range addresses {
+		key, err := decodeKS2KeyInDir(address, timeDir)
+		if err != nil {
+			return restore(errors.New(strings.Join([]string{"verify rotated keystore", address, "error", err.Error()}, " ")))
+		}
+		if !strings.EqualFold(key.Address.Hex(), address) {
+			return restore(errors.New(strings.Join([]string{"verify rotated keystore", address, "address mismatch"}, " ")))
+		}
+	}
+
+	if err := updateManifestKeystores(timeDir, addresses); err != nil {
+		return restore(errors.New(strings.Join([]string{"update manifest error", err.Error()}, " ")))
+	}
+
+	if err := auditLog("rotate-passwords", log.Fields{
+		"batch":     timeDir,
+		"addresses": addresses,
+	}); err != nil {
+		log.Errorln("write audit log error", err.Error())
+	}
+	return discard(nil)
+}
+
+func replacePwdEntry(pwds []*RandomPwdJSON, address, randomPwd string) []*RandomPwdJSON {
+	for _, randompwd := range pwds {
+		if randompwd.Address == address {
+			randompwd.Randompwd = randomPwd
+			return pwds
+		}
+	}
+	return append(pwds, &RandomPwdJSON{address, randomPwd})
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	letterSecretAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitSecretAlphabet  = "0123456789"
+	symbolSecretAlphabet = "!#$%&()*+,-./:;<=>?@[]^_{|}~"
+
+	// minSecretLength shortest keystore password
+	minSecretLength = 16
+)
+
+// secretAlphabets 随机密码字符集
+var secretAlphabets = map[string]string{
+	"letters":   letterSecretAlphabet,
+	"alnum":     letterSecretAlphabet + digitSecretAlphabet,
+	"printable": letterSecretAlphabet + digitSecretAlphabet + symbolSecretAlphabet,
+}
+
+// secretGenerator 基于 crypto/rand 的随机密码生成器，同一批次内拒绝重复输出
+type secretGenerator struct {
+	alphabet string
+	mu       sync.Mutex
+	issued   map[string]bool
+}
+
+// secret keystore passwords and AES keys of the current batch
+var secret = &secretGenerator{alphabet: letterSecretAlphabet, issued: map[string]bool{}}
+
+func newSecretGenerator(alphabetName string) (*secretGenerator, error) {
+	alphabet, ok := secretAlphabets[strings.ToLower(alphabetName)]
+	if !ok {
+		return nil, errors.New(strings.Join([]string{"unknown secret alphabet:", alphabetName}, " "))
+	}
+	return &secretGenerator{alphabet: alphabet, issued: map[string]bool{}}, nil
+}
+
+// generate n characters of the alphabet, read from crypto/rand with rejection sampling to avoid modulo bias
+func (g *secretGenerator) generate(n int) (string, error) {
+	if n <= 0 {
+		return "", errors.New("secret length must be positive")
+	}
+
+	alphabetLen := len(g.alphabet)
+	// largest multiple of the alphabet length within a byte
+	maxByte := 256 - 256%alphabetLen
+	b := make([]byte, 0, n)
+	buf := make([]byte, n*2)
+	for len(b) < n {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, r := range buf {
+			if int(r) >= maxByte {
+				continue
+			}
+			b = append(b, g.alphabet[int(r)%alphabetLen])
+			if len(b) == n {
+				break
+			}
+		}
+	}
+
+	s := string(b)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.issued[s] {
+		return "", errors.New("secret generator produced a duplicate output in the batch")
+	}
+	g.issued[s] = true
+	return s, nil
+}
+
+// secretSelfTest generate a batch with a throwaway generator, reject duplicate output,
+// characters outside the alphabet or an alphabet character never produced
+func secretSelfTest(alphabet string, length, batch int) error {
+	g := &secretGenerator{alphabet: alphabet, issued: map[string]bool{}}
+	seen := map[rune]bool{}
+	for i := 0; i < batch; i++ {
+		s, err := g.generate(length)
+		if err != nil {
+			return errors.New(strings.Join([]string{"secret self-test failed:", err.Error()}, " "))
+		}
+		for _, c := range s {
+			if !strings.ContainsRune(alphabet, c) {
+				return errors.New(strings.Join([]string{"secret self-test failed: character outside alphabet", strconv.QuoteRune(c)}, " "))
+			}
+			seen[c] = true
+		}
+	}
+	if len(seen) != len(alphabet) {
+		return errors.New(strings.Join([]string{"secret self-test failed: only", strconv.Itoa(len(seen)), "of", strconv.Itoa(len(alphabet)), "alphabet characters produced"}, " "))
+	}
+	return nil
+}
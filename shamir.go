@@ -0,0 +1,191 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Shamir's secret sharing over GF(2^8), polynomial x^8 + x^4 + x^3 + x + 1 (same field as AES).
+// A share is the secret length of y values followed by one byte x coordinate.
+
+var (
+	gfExp [512]byte
+	gfLog [256]byte
+)
+
+func init() {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		gfExp[i] = x
+		gfLog[x] = byte(i)
+		// multiply by generator 3
+		x ^= gfMulNoTable(x, 2)
+	}
+	for i := 255; i < 512; i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+}
+
+func gfMulNoTable(a, b byte) byte {
+	var p byte
+	for b > 0 {
+		if b&1 == 1 {
+			p ^= a
+		}
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+	return p
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+255-int(gfLog[b])]
+}
+
+// shamirSplit split secret into parts shares, any threshold of them restore the secret
+func shamirSplit(secret []byte, parts, threshold int) ([][]byte, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("shamir: secret is empty")
+	}
+	if threshold < 2 || parts < threshold || parts > 255 {
+		return nil, errors.New("shamir: require 2 <= threshold <= shares <= 255")
+	}
+
+	shares := make([][]byte, parts)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+1)
+		shares[i][len(secret)] = byte(i + 1)
+	}
+
+	coefficients := make([]byte, threshold)
+	for idx, b := range secret {
+		// random polynomial with the secret byte as intercept
+		coefficients[0] = b
+		if _, err := rand.Read(coefficients[1:]); err != nil {
+			return nil, err
+		}
+		for i := range shares {
+			x := shares[i][len(secret)]
+			// horner's method
+			var y byte
+			for c := threshold - 1; c >= 0; c-- {
+				y = gfMul(y, x) ^ coefficients[c]
+			}
+			shares[i][idx] = y
+		}
+	}
+	return shares, nil
+}
+
+// shamirCombine restore the secret by lagrange interpolation at x = 0
+func shamirCombine(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, errors.New("shamir: at least two shares are required")
+	}
+	shareLen := len(shares[0])
+	if shareLen < 2 {
+		return nil, errors.New("shamir: share too short")
+	}
+
+	xs := make([]byte, len(shares))
+	seen := map[byte]bool{}
+	for i, share := range shares {
+		if len(share) != shareLen {
+			return nil, errors.New("shamir: shares have different length")
+		}
+		x := share[shareLen-1]
+		if x == 0 || seen[x] {
+			return nil, errors.New("shamir: duplicate or invalid share")
+		}
+		seen[x] = true
+		xs[i] = x
+	}
+
+	secret := make([]byte, shareLen-1)
+	for idx := range secret {
+		var y byte
+		for i := range shares {
+			// lagrange basis polynomial evaluated at 0
+			basis := byte(1)
+			for j := range shares {
+				if i == j {
+					continue
+				}
+				basis = gfMul(basis, gfDiv(xs[j], xs[i]^xs[j]))
+			}
+			y ^= gfMul(shares[i][idx], basis)
+		}
+		secret[idx] = y
+	}
+	return secret, nil
+}
+
+// encodeShare qrcode text of a share: shamir:<threshold>:<shares>:<base64 share>
+func encodeShare(share []byte, parts, threshold int) string {
+	return strings.Join([]string{"shamir", strconv.Itoa(threshold), strconv.Itoa(parts), base64.StdEncoding.EncodeToString(share)}, ":")
+}
+
+func decodeShare(text string) ([]byte, int, int, error) {
+	fields := strings.Split(text, ":")
+	if len(fields) != 4 || fields[0] != "shamir" {
+		return nil, 0, 0, errors.New("not a shamir share qrcode")
+	}
+	threshold, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, 0, 0, errors.New(strings.Join([]string{"invalid share threshold", err.Error()}, " "))
+	}
+	parts, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, 0, 0, errors.New(strings.Join([]string{"invalid share parts", err.Error()}, " "))
+	}
+	share, err := base64.StdEncoding.DecodeString(fields[3])
+	if err != nil {
+		return nil, 0, 0, errors.New(strings.Join([]string{"invalid share data", err.Error()}, " "))
+	}
+	return share, threshold, parts, nil
+}
+
+// combineShareTexts restore the secret from shamir share qrcode texts, all shares must come from the same split
+func combineShareTexts(texts []string) ([]byte, error) {
+	if len(texts) == 0 {
+		return nil, errors.New("shamir: no share")
+	}
+	shares := [][]byte{}
+	threshold, parts := 0, 0
+	for i, text := range texts {
+		share, t, n, err := decodeShare(text)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			threshold, parts = t, n
+		} else if t != threshold || n != parts {
+			return nil, errors.New(strings.Join([]string{"shamir: share", strconv.Itoa(i + 1), "is", strconv.Itoa(t), "of", strconv.Itoa(n), "but share 1 is", strconv.Itoa(threshold), "of", strconv.Itoa(parts)}, " "))
+		}
+		shares = append(shares, share)
+	}
+	if threshold < 2 || parts < threshold {
+		return nil, errors.New(strings.Join([]string{"shamir: invalid share header", strconv.Itoa(threshold), "of", strconv.Itoa(parts)}, " "))
+	}
+	if len(shares) < threshold {
+		return nil, errors.New(strings.Join([]string{"shamir: need", strconv.Itoa(threshold), "shares, got", strconv.Itoa(len(shares))}, " "))
+	}
+	return shamirCombine(shares)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// subsets every combination of size k of the indexes 0..n-1
+func subsets(n, k int) [][]int {
+	if k == 0 {
+		return [][]int{{}}
+	}
+	result := [][]int{}
+	for first := 0; first <= n-k; first++ {
+		for _, rest := range subsets(n-first-1, k-1) {
+			subset := []int{first}
+			for _, r := range rest {
+				subset = append(subset, first+1+r)
+			}
+			result = append(result, subset)
+		}
+	}
+	return result
+}
+
+func TestShamirEverySubset(t *testing.T) {
+	secret := []byte("legal winner thank year wave sausage worth useful legal winner thank yellow")
+	for _, c := range []struct{ parts, threshold int }{{2, 2}, {3, 2}, {5, 3}, {6, 4}} {
+		shares, err := shamirSplit(secret, c.parts, c.threshold)
+		if err != nil {
+			t.Fatalf("%d of %d split: %v", c.threshold, c.parts, err)
+		}
+		texts := make([]string, len(shares))
+		for i, share := range shares {
+			texts[i] = encodeShare(share, c.parts, c.threshold)
+		}
+
+		for k := c.threshold; k <= c.parts; k++ {
+			for _, subset := range subsets(c.parts, k) {
+				picked := []string{}
+				for _, i := range subset {
+					picked = append(picked, texts[i])
+				}
+				got, err := combineShareTexts(picked)
+				if err != nil {
+					t.Fatalf("%d of %d shares %v: %v", c.threshold, c.parts, subset, err)
+				}
+				if !bytes.Equal(got, secret) {
+					t.Fatalf("%d of %d shares %v restored %q", c.threshold, c.parts, subset, got)
+				}
+			}
+		}
+	}
+}
+
+func TestShamirTooFewShares(t *testing.T) {
+	shares, err := shamirSplit([]byte("secret"), 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, subset := range subsets(5, 2) {
+		picked := []string{}
+		for _, i := range subset {
+			picked = append(picked, encodeShare(shares[i], 5, 3))
+		}
+		if _, err := combineShareTexts(picked); err == nil || !strings.Contains(err.Error(), "need 3 shares") {
+			t.Fatalf("shares %v: expected need 3 shares error, got %v", subset, err)
+		}
+	}
+	if _, err := combineShareTexts(nil); err == nil {
+		t.Fatal("expected error without shares")
+	}
+}
+
+func TestShamirDuplicateShare(t *testing.T) {
+	shares, err := shamirSplit([]byte("secret"), 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := encodeShare(shares[0], 3, 2)
+	if _, err := combineShareTexts([]string{text, text}); err == nil {
+		t.Fatal("expected error for a duplicate share")
+	}
+
+	// same x coordinate with different y values
+	forged := append([]byte{}, shares[1]...)
+	forged[len(forged)-1] = shares[0][len(shares[0])-1]
+	if _, err := combineShareTexts([]string{text, encodeShare(forged, 3, 2)}); err == nil {
+		t.Fatal("expected error for a duplicate share index")
+	}
+}
+
+func TestShamirMismatchedHeader(t *testing.T) {
+	first, err := shamirSplit([]byte("secret one"), 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := shamirSplit([]byte("secret two"), 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	texts := []string{encodeShare(first[0], 3, 2), encodeShare(second[1], 5, 3), encodeShare(second[2], 5, 3)}
+	if _, err := combineShareTexts(texts); err == nil {
+		t.Fatal("expected error for shares of different splits")
+	}
+	// same threshold, different parts
+	texts = []string{encodeShare(first[0], 3, 2), encodeShare(first[1], 4, 2)}
+	if _, err := combineShareTexts(texts); err == nil {
+		t.Fatal("expected error for shares with different parts")
+	}
+}
+
+func TestShamirSplitArguments(t *testing.T) {
+	if _, err := shamirSplit(nil, 3, 2); err == nil {
+		t.Fatal("expected error for empty secret")
+	}
+	for _, c := range []struct{ parts, threshold int }{{3, 1}, {2, 3}, {256, 2}} {
+		if _, err := shamirSplit([]byte("secret"), c.parts, c.threshold); err == nil {
+			t.Fatalf("expected error for %d of %d", c.threshold, c.parts)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"strconv"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -12,7 +13,7 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-func sync() {
+func syncChainData() {
 	ctx := context.Background()
 	nodeClient, err := ethclient.Dial(config.EthRPC)
 	if err != nil {
@@ -86,7 +87,7 @@ func esBlockFunc(block *types.Block) interface{} {
 	b := map[string]interface{}{
 		"height":     block.Header().Number,
 		"hash":       block.Hash().Hex(),
-		"time":       block.Time().String(),
+		"time":       strconv.FormatUint(block.Time(), 10),
 		"parenthash": block.ParentHash().Hex(),
 		"sha3uncles": block.UncleHash().Hex(),
 		"miner":      block.Coinbase().Hex(),
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// defaultTokenGasLimit transfer gas limit when the backend can not estimate gas
+const defaultTokenGasLimit = 100000
+
+var (
+	// balanceOfSelector keccak256("balanceOf(address)")[:4]
+	balanceOfSelector = []byte{0x70, 0xa0, 0x82, 0x31}
+	// transferSelector keccak256("transfer(address,uint256)")[:4]
+	transferSelector = []byte{0xa9, 0x05, 0x9c, 0xbb}
+)
+
+// tokenConfig ERC-20 代币配置
+type tokenConfig struct {
+	symbol   string
+	contract common.Address
+	decimals int32
+	// minSweep balances below it in token units are left in the address
+	minSweep float64
+	// gasLimit of transfer on the etherscan backend, which can not estimate gas of the sender
+	gasLimit uint64
+}
+
+// parseTokenConfig read a token of the tokens block of configure, symbol defaults to the upper case key
+func parseTokenConfig(name string, v *viper.Viper) *tokenConfig {
+	t := &tokenConfig{
+		symbol:   strings.ToUpper(name),
+		decimals: int32(v.GetInt("decimals")),
+		minSweep: v.GetFloat64("min_sweep"),
+		gasLimit: defaultTokenGasLimit,
+	}
+	if v.IsSet("symbol") {
+		t.symbol = v.GetString("symbol")
+	}
+	if v.IsSet("gas_limit") {
+		t.gasLimit = uint64(v.GetInt64("gas_limit"))
+	}
+	// an invalid contract stays zero and is rejected by validate
+	if common.IsHexAddress(v.GetString("contract")) {
+		t.contract = common.HexToAddress(v.GetString("contract"))
+	}
+	return t
+}
+
+func (t *tokenConfig) validate() error {
+	if t.contract == (common.Address{}) {
+		return errors.New(strings.Join([]string{"token", t.symbol, "requires a valid contract address"}, " "))
+	}
+	if t.decimals < 0 || t.decimals > 77 {
+		return errors.New(strings.Join([]string{"token", t.symbol, "invalid decimals", strconv.Itoa(int(t.decimals))}, " "))
+	}
+	if t.minSweep < 0 {
+		return errors.New(strings.Join([]string{"token", t.symbol, "min_sweep must not be negative"}, " "))
+	}
+	if t.gasLimit < 21000 {
+		return errors.New(strings.Join([]string{"token", t.symbol, "gas_limit must be at least 21000"}, " "))
+	}
+	return nil
+}
+
+// formatAmount amount in token units
+func (t *tokenConfig) formatAmount(amount *big.Int) string {
+	return decimal.NewFromBigInt(amount, -t.decimals).String()
+}
+
+// lookupTokens resolve --token names or contract addresses against the registry, all is every registered token by symbol
+func lookupTokens(names []string) ([]*tokenConfig, error) {
+	tokens := []*tokenConfig{}
+	if len(names) == 1 && strings.ToLower(names[0]) == "all" {
+		for _, t := range config.Tokens {
+			tokens = append(tokens, t)
+		}
+		sort.Slice(tokens, func(i, j int) bool { return tokens[i].symbol < tokens[j].symbol })
+	} else {
+		for _, name := range names {
+			t := lookupToken(name)
+			if t == nil {
+				return nil, errors.New(strings.Join([]string{"token", name, "is not in tokens of configure"}, " "))
+			}
+			tokens = append(tokens, t)
+		}
+	}
+
+	for _, t := range tokens {
+		if err := t.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return tokens, nil
+}
+
+// lookupToken by key or symbol in the registry, or by contract address
+func lookupToken(name string) *tokenConfig {
+	if t, ok := config.Tokens[strings.ToLower(name)]; ok {
+		return t
+	}
+	if common.IsHexAddress(name) {
+		return tokenByContract(common.HexToAddress(name))
+	}
+	for _, t := range config.Tokens {
+		if strings.EqualFold(t.symbol, name) {
+			return t
+		}
+	}
+	return nil
+}
+
+func tokenByContract(contract common.Address) *tokenConfig {
+	for _, t := range config.Tokens {
+		if t.contract == contract {
+			return t
+		}
+	}
+	return nil
+}
+
+// balanceOfData balanceOf(owner) call data
+func balanceOfData(owner common.Address) []byte {
+	return append(append([]byte{}, balanceOfSelector...), common.LeftPadBytes(owner.Bytes(), 32)...)
+}
+
+// transferData transfer(to, amount) call data
+func transferData(to common.Address, amount *big.Int) []byte {
+	data := append([]byte{}, transferSelector...)
+	data = append(data, common.LeftPadBytes(to.Bytes(), 32)...)
+	return append(data, common.LeftPadBytes(amount.Bytes(), 32)...)
+}
+
+// decodeTransferData recipient and amount of transfer(to, amount) call data
+func decodeTransferData(data []byte) (*common.Address, *big.Int, error) {
+	if len(data) != 4+32*2 || !bytes.Equal(data[:4], transferSelector) {
+		return nil, nil, errors.New("call data is not an ERC-20 transfer")
+	}
+	if !bytes.Equal(data[4:16], make([]byte, 12)) {
+		return nil, nil, errors.New("invalid ERC-20 transfer recipient")
+	}
+	to := common.BytesToAddress(data[16:36])
+	return &to, new(big.Int).SetBytes(data[36:68]), nil
+}
+
+// txRecipient who receives the funds: the to address of a plain transfer, or the recipient of an ERC-20 transfer
+// of a registered token. Any other call data is refused.
+func txRecipient(tx *types.Transaction) (*common.Address, *tokenConfig, *big.Int, error) {
+	if tx.To() == nil {
+		return nil, nil, nil, errors.New("contract creation is not supported")
+	}
+	if len(tx.Data()) == 0 {
+		return tx.To(), nil, tx.Value(), nil
+	}
+
+	token := tokenByContract(*tx.To())
+	if token == nil {
+		return nil, nil, nil, errors.New(strings.Join([]string{"call data to", tx.To().Hex(), "which is not in tokens of configure"}, " "))
+	}
+	if tx.Value().Sign() != 0 {
+		return nil, nil, nil, errors.New(strings.Join([]string{token.symbol, "transfer must not carry value"}, " "))
+	}
+	to, amount, err := decodeTransferData(tx.Data())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return to, token, amount, nil
+}
+
+// checkTxToken the recipient, token and amount in the tx file are what the operator reviews, they must match the signed bytes
+func checkTxToken(simpletx *Tx, to *common.Address, token *tokenConfig, amount *big.Int) error {
+	mismatch := errors.New(strings.Join([]string{"tx file recipient or token does not match txhex of", simpletx.From}, " "))
+	if simpletx.To != "" && !strings.EqualFold(simpletx.To, to.Hex()) {
+		return mismatch
+	}
+	if token == nil {
+		if simpletx.Token != "" {
+			return mismatch
+		}
+		return nil
+	}
+	if simpletx.Token != token.symbol || simpletx.Amount == nil || simpletx.Amount.Cmp(amount) != 0 {
+		return mismatch
+	}
+	return nil
+}
+
+// tokenBalance balanceOf(address) of the token through the geth, parity or etherscan backend
+func tokenBalance(token *tokenConfig, address string) (*big.Int, error) {
+	switch node {
+	case "geth", "parity":
+		client, err := nodeClient(node)
+		if err != nil {
+			return nil, err
+		}
+		defer client.Close()
+
+		result, err := client.CallContract(context.Background(), ethereum.CallMsg{
+			To:   &token.contract,
+			Data: balanceOfData(common.HexToAddress(address)),
+		}, nil)
+		if err != nil {
+			return nil, errors.New(strings.Join([]string{token.symbol, "balanceOf error", err.Error()}, " "))
+		}
+		if len(result) != 32 {
+			return nil, errors.New(strings.Join([]string{token.symbol, "balanceOf returns unexpected data, check the contract address"}, " "))
+		}
+		return new(big.Int).SetBytes(result), nil
+	case "etherscan":
+		return etherscan.getTokenBalance(token.contract.Hex(), address)
+	default:
+		return nil, errors.New("Only support geth, parity, etherscan")
+	}
+}
+
+// estimateTokenGas gas limit of the transfer, the estimate of geth or parity plus 20% margin,
+// the configured gas limit of the token on etherscan
+func estimateTokenGas(token *tokenConfig, from string, data []byte) (uint64, error) {
+	if node == "etherscan" {
+		return token.gasLimit, nil
+	}
+
+	client, err := nodeClient(node)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	gas, err := client.EstimateGas(context.Background(), ethereum.CallMsg{
+		From: common.HexToAddress(from),
+		To:   &token.contract,
+		Data: data,
+	})
+	if err != nil {
+		return 0, errors.New(strings.Join([]string{token.symbol, "estimate gas error", err.Error()}, " "))
+	}
+	return gasWithHeadroom(gas), nil
+}
+
+// accountState balance, pending nonce and fee market of address, without the max_balance threshold of native sweeps
+func accountState(address string) (*big.Int, *uint64, *chainFee, error) {
+	switch node {
+	case "geth", "parity":
+		client, err := nodeClient(node)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		defer client.Close()
+
+		ctx := context.Background()
+		account := common.HexToAddress(address)
+		balance, err := client.BalanceAt(ctx, account, nil)
+		if err != nil {
+			return nil, nil, nil, errors.New(strings.Join([]string{"get balance error", address, err.Error()}, " "))
+		}
+		nonce, err := client.PendingNonceAt(ctx, account)
+		if err != nil {
+			return nil, nil, nil, errors.New(strings.Join([]string{"get pending nonce error", address, err.Error()}, " "))
+		}
+		fee, err := nodeChainFee(client)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return balance, &nonce, fee, nil
+	case "etherscan":
+		balance, err := etherscan.getBalance(address)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		nonce, err := etherscan.getAccountNonce(address)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		fee, err := etherscan.etherscanChainFee()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return balance, nonce, fee, nil
+	default:
+		return nil, nil, nil, errors.New("Only support geth, parity, etherscan")
+	}
+}
+
+// tokenTransfer a planned transfer of the whole token balance
+type tokenTransfer struct {
+	token      *tokenConfig
+	to         common.Address
+	amount     *big.Int
+	data       []byte
+	gasLimit   uint64
+	accessList types.AccessList
+	txFee      *big.Int
+}
+
+// constructTokenTxs one transfer per token with a balance of at least min_sweep, at consecutive nonces.
+// The native balance of the address pays the gas, the gas station tops up the shortfall when it is configured,
+// otherwise the transfers the address can not afford are skipped. Return the top-up and nonce of the funding tx.
+func constructTokenTxs(from string, tokens []*tokenConfig, station *gasStation) (*big.Int, *uint64, error) {
+	balance, nonce, fee, err := accountState(from)
+	if err != nil {
+		return nil, nil, err
+	}
+	feeCap, _, err := feePerGas(fee)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transfers := []*tokenTransfer{}
+	gasNeeded := new(big.Int)
+	for _, token := range tokens {
+		amount, err := tokenBalance(token, from)
+		if err != nil {
+			log.Warnln(err.Error())
+			continue
+		}
+		if amount.Sign() == 0 || decimal.NewFromBigInt(amount, -token.decimals).LessThan(decimal.NewFromFloat(token.minSweep)) {
+			log.Infoln("Ignore:", from, token.symbol, "balance", token.formatAmount(amount), "less than min_sweep")
+			continue
+		}
+
+		to := common.HexToAddress(randomPickFromSlice(config.To))
+		data := transferData(to, amount)
+		var accessList types.AccessList
+		gasLimit, err := estimateTokenGas(token, from, data)
+		if err != nil {
+			log.Warnln(err.Error())
+			continue
+		}
+		if withAccessList {
+			list, gasUsed, err := createAccessList(node, from, token.contract.Hex(), new(big.Int), data)
+			if err != nil {
+				log.Warnln(err.Error())
+				continue
+			}
+			accessList, gasLimit = append(types.AccessList{}, list...), gasWithHeadroom(gasUsed)
+		}
+
+		txFee := new(big.Int).Mul(feeCap, new(big.Int).SetUint64(gasLimit))
+		if station == nil && new(big.Int).Add(gasNeeded, txFee).Cmp(balance) > 0 {
+			log.Warnln("Ignore:", from, "balance", balance.String(), "does not cover the gas of", token.symbol, "transfer", txFee.String())
+			continue
+		}
+		gasNeeded.Add(gasNeeded, txFee)
+		transfers = append(transfers, &tokenTransfer{token, to, amount, data, gasLimit, accessList, txFee})
+	}
+	if len(transfers) == 0 {
+		return nil, nil, nil
+	}
+
+	// the funding tx is exported first, the transfers wait for it in send
+	var topUp *big.Int
+	var fundingNonce *uint64
+	if gasNeeded.Cmp(balance) > 0 {
+		topUp = new(big.Int).Sub(gasNeeded, balance)
+		if fundingNonce, err = station.fund(from, topUp); err != nil {
+			return nil, nil, errors.New(strings.Join([]string{"Ignore:", from, "gas top-up error", err.Error()}, " "))
+		}
+	}
+
+	next := *nonce
+	for _, transfer := range transfers {
+		token := transfer.token
+		tx, err := newTx(next, fee, transfer.gasLimit, transfer.accessList, token.contract, new(big.Int), transfer.data)
+		if err != nil {
+			return topUp, fundingNonce, err
+		}
+		rawTxHex, err := encodeTx(tx)
+		if err != nil {
+			return topUp, fundingNonce, errors.New(strings.Join([]string{"encode raw tx error", err.Error()}, " "))
+		}
+		simpletx := &Tx{
+			From:       from,
+			To:         transfer.to.Hex(),
+			TxHex:      *rawTxHex,
+			Nonce:      next,
+			Hash:       tx.Hash().Hex(),
+			Type:       tx.Type(),
+			AccessList: tx.AccessList(),
+			Token:      token.symbol,
+			Contract:   token.contract.Hex(),
+			Amount:     transfer.amount,
+		}
+		if fundingNonce != nil {
+			simpletx.FundedBy, simpletx.FundingNonce = station.address, *fundingNonce
+		}
+		if err := exportHexTx(simpletx, false); err != nil {
+			return topUp, fundingNonce, errors.New(strings.Join([]string{"sub address:", from, "fail to export", token.symbol, "rawTxHex to", config.RawTx, err.Error()}, " "))
+		}
+		log.Infoln("construct", token.symbol, "transfer", token.formatAmount(transfer.amount), "from", from, "nonce", next)
+		next++
+	}
+	return topUp, fundingNonce, nil
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"io/ioutil"
 	"math/big"
+	"sort"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
@@ -26,30 +27,41 @@ type Tx struct {
 	Value big.Int `json:"value"`
 	Nonce uint64  `json:"nonce"`
 	Hash  string  `json:"hash"`
+	// Type 0 legacy, 1 EIP-2930 access list, 2 EIP-1559 dynamic fee
+	Type       uint8            `json:"type,omitempty"`
+	AccessList types.AccessList `json:"accessList,omitempty"`
+	// Token symbol, contract and amount of ERC-20 transfer, To is the token recipient
+	Token    string   `json:"token,omitempty"`
+	Contract string   `json:"contract,omitempty"`
+	Amount   *big.Int `json:"amount,omitempty"`
+	// Funds the deposit address a gas station funding tx tops up
+	Funds string `json:"funds,omitempty"`
+	// FundedBy and FundingNonce the funding tx which must be mined before the token transfer is sent
+	FundedBy     string `json:"fundedBy,omitempty"`
+	FundingNonce uint64 `json:"fundingNonce,omitempty"`
 }
 
-func exportHexTx(from, to, txHex, hash string, value *big.Int, nonce *uint64, signed bool) error {
-	tx := &Tx{
-		From:  from,
-		To:    to,
-		TxHex: txHex,
-		Value: *value,
-		Nonce: *nonce,
-		Hash:  hash,
-	}
-
+func exportHexTx(tx *Tx, signed bool) error {
 	bTx, err := json.Marshal(tx)
 	if err != nil {
 		return err
 	}
 
+	// one file per address and token, a funding tx is named after the address it funds
+	nameParts := []string{tx.From}
+	if tx.Token != "" {
+		nameParts = append(nameParts, tx.Token)
+	}
+	if tx.Funds != "" {
+		nameParts = append(nameParts, "fund", tx.Funds)
+	}
 	var configurePath, txFileName string
 	if signed {
 		configurePath = config.SignedTx
-		txFileName = strings.Join([]string{"signed_from", from, "json"}, ".")
+		txFileName = strings.Join(append(append([]string{"signed_from"}, nameParts...), "json"), ".")
 	} else {
 		configurePath = config.RawTx
-		txFileName = strings.Join([]string{"unsign_from", from, "json"}, ".")
+		txFileName = strings.Join(append(append([]string{"unsign_from"}, nameParts...), "json"), ".")
 	}
 	TxPath, err := mkdirBySlice([]string{HomeDir(), configurePath})
 	if err != nil {
@@ -64,80 +76,185 @@ func exportHexTx(from, to, txHex, hash string, value *big.Int, nonce *uint64, si
 	return nil
 }
 
-func constructTxCmd() {
+func constructTxCmd(tokens []*tokenConfig) {
 	ormDB := ormBbAlias{dbConn()}
 	ormDB.DBMigrate()
 	defer ormDB.Close()
 	ormDB.csv2db()
 
+	if sweepDust {
+		ormDB.constructDustTxs()
+		return
+	}
+
+	var station *gasStation
+	if len(tokens) > 0 && config.GasStation != "" {
+		var err error
+		if station, err = newGasStation(); err != nil {
+			log.Fatalln(err.Error())
+		}
+	}
+
 	var subAddresses []*SubAddress
 	ormDB.Find(&subAddresses)
 	for _, subaddress := range subAddresses {
-		from, balance, pendingNonceAt, gasPrice, err := ormDB.constructTxField(subaddress.Address)
+		if len(tokens) > 0 {
+			topUp, fundingNonce, err := constructTokenTxs(subaddress.Address, tokens, station)
+			if err != nil {
+				log.Warnln(err.Error())
+			}
+			if fundingNonce != nil {
+				ormDB.recordGasDust(subaddress.Address, station.address, *fundingNonce, topUp)
+			}
+			continue
+		}
+
+		from, balance, pendingNonceAt, fee, err := ormDB.constructTxField(subaddress.Address)
 		if err != nil {
 			log.Warnln(err.Error())
 			continue
 		}
 
 		to := randomPickFromSlice(config.To)
-		if err := applyWithdrawAndConstructRawTx(balance, gasPrice, pendingNonceAt, *from, to); err != nil {
+		if err := applyWithdrawAndConstructRawTx(balance, fee, pendingNonceAt, *from, to); err != nil {
 			log.Warnln(err.Error())
 		}
 	}
 }
 
-func applyWithdrawAndConstructRawTx(balance, gasPrice *big.Int, nonce *uint64, from, to string) error {
+func applyWithdrawAndConstructRawTx(balance *big.Int, fee *chainFee, nonce *uint64, from, to string) error {
 	if err := balanceIsLessThanConfig(from, balance); err != nil {
 		return err
 	}
+	return constructRawTx(balance, fee, nonce, from, to)
+}
 
-	fromHex, toHex, rawTxHex, txHashHex, value, err := constructTx(*nonce, balance, gasPrice, from, to)
+// gasWithHeadroom gas limit of a measured or estimated call, 20% above the gas it used
+func gasWithHeadroom(gasUsed uint64) uint64 {
+	return gasUsed + gasUsed/5
+}
+
+// constructRawTx export the sweep of the whole balance
+func constructRawTx(balance *big.Int, fee *chainFee, nonce *uint64, from, to string) error {
+	gasLimit := uint64(21000) // in units
+	var accessList types.AccessList
+	if withAccessList {
+		list, gasUsed, err := createAccessList(node, from, to, balance, nil)
+		if err != nil {
+			return err
+		}
+		// non nil even if empty, makes a typed transaction
+		accessList, gasLimit = append(types.AccessList{}, list...), gasWithHeadroom(gasUsed)
+	}
+
+	fromHex, toHex, rawTxHex, txHashHex, value, tx, err := constructTx(*nonce, balance, fee, gasLimit, accessList, from, to)
 	if err != nil {
 		return errors.New(strings.Join([]string{"constructTx error", err.Error()}, " "))
 	}
-	if err := exportHexTx(*fromHex, *toHex, *rawTxHex, *txHashHex, value, nonce, false); err != nil {
+	if err := exportHexTx(&Tx{
+		From:       *fromHex,
+		To:         *toHex,
+		TxHex:      *rawTxHex,
+		Value:      *value,
+		Nonce:      *nonce,
+		Hash:       *txHashHex,
+		Type:       tx.Type(),
+		AccessList: tx.AccessList(),
+	}, false); err != nil {
 		return errors.New(strings.Join([]string{"sub address:", from, "hased applied withdraw, but fail to export rawTxHex to ", config.RawTx, err.Error()}, " "))
 	}
 	return nil
 }
 
-func constructTx(nonce uint64, balance, gasPrice *big.Int, hexAddressFrom, hexAddressTo string) (*string, *string, *string, *string, *big.Int, error) {
-	gasLimit := uint64(21000) // in units
-
+// constructTx sweep the balance minus the worst case fee
+func constructTx(nonce uint64, balance *big.Int, fee *chainFee, gasLimit uint64, accessList types.AccessList, hexAddressFrom, hexAddressTo string) (*string, *string, *string, *string, *big.Int, *types.Transaction, error) {
 	if !common.IsHexAddress(hexAddressTo) {
-		return nil, nil, nil, nil, nil, errors.New(strings.Join([]string{hexAddressTo, "invalidate"}, " "))
+		return nil, nil, nil, nil, nil, nil, errors.New(strings.Join([]string{hexAddressTo, "invalidate"}, " "))
 	}
 
-	var (
-		txFee = new(big.Int)
-		value = new(big.Int)
-	)
-
-	txFee = txFee.Mul(gasPrice, big.NewInt(int64(gasLimit)))
-	value = value.Sub(balance, txFee)
+	feeCap, _, err := feePerGas(fee)
+	if err != nil {
+		return nil, nil, nil, nil, nil, nil, err
+	}
+	// the unused part of the max fee stays in the account
+	txFee := new(big.Int).Mul(feeCap, new(big.Int).SetUint64(gasLimit))
+	value := new(big.Int).Sub(balance, txFee)
+	if value.Sign() <= 0 {
+		return nil, nil, nil, nil, nil, nil, errors.New(strings.Join([]string{hexAddressFrom, "balance does not cover the fee", txFee.String()}, " "))
+	}
 
-	tx := types.NewTransaction(nonce, common.HexToAddress(hexAddressTo), value, gasLimit, gasPrice, nil)
+	tx, err := newTx(nonce, fee, gasLimit, accessList, common.HexToAddress(hexAddressTo), value, nil)
+	if err != nil {
+		return nil, nil, nil, nil, nil, nil, err
+	}
 	rawTxHex, err := encodeTx(tx)
 	if err != nil {
-		return nil, nil, nil, nil, nil, errors.New(strings.Join([]string{"encode raw tx error", err.Error()}, " "))
+		return nil, nil, nil, nil, nil, nil, errors.New(strings.Join([]string{"encode raw tx error", err.Error()}, " "))
 	}
 	txHashHex := tx.Hash().Hex()
-	return &hexAddressFrom, &hexAddressTo, rawTxHex, &txHashHex, value, nil
+	return &hexAddressFrom, &hexAddressTo, rawTxHex, &txHashHex, value, tx, nil
+}
+
+// feePerGas worst case fee per gas and priority fee of the configured fee mode, legacy has no priority fee
+func feePerGas(fee *chainFee) (*big.Int, *big.Int, error) {
+	if config.Fee.mode == "dynamic" {
+		tip, maxFee, err := config.Fee.dynamicFees(fee)
+		if err != nil {
+			return nil, nil, err
+		}
+		return maxFee, tip, nil
+	}
+	return fee.gasPrice, nil, nil
+}
+
+// newTx a non nil accessList makes EIP-2930 type 1 transaction, or is carried by the type 2 transaction
+func newTx(nonce uint64, fee *chainFee, gasLimit uint64, accessList types.AccessList, to common.Address, value *big.Int, data []byte) (*types.Transaction, error) {
+	feeCap, tip, err := feePerGas(fee)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Fee.mode == "dynamic" {
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:    chain.chainID,
+			Nonce:      nonce,
+			GasTipCap:  tip,
+			GasFeeCap:  feeCap,
+			Gas:        gasLimit,
+			To:         &to,
+			Value:      value,
+			Data:       data,
+			AccessList: accessList,
+		}), nil
+	}
+	if accessList != nil {
+		return types.NewTx(&types.AccessListTx{
+			ChainID:    chain.chainID,
+			Nonce:      nonce,
+			GasPrice:   feeCap,
+			Gas:        gasLimit,
+			To:         &to,
+			Value:      value,
+			Data:       data,
+			AccessList: accessList,
+		}), nil
+	}
+	return types.NewTransaction(nonce, to, value, gasLimit, feeCap, data), nil
 }
 
-func nodeConstructTxField(node, address string) (*big.Int, *uint64, *big.Int, error) {
+func nodeConstructTxField(node, address string) (*big.Int, *uint64, *chainFee, error) {
 	client, err := nodeClient(node)
 	if err != nil {
 		return nil, nil, nil, err
 	}
-	balance, nonce, gasPrice, err := getBalanceAndPendingNonceAtAndGasPrice(client, address)
+	balance, nonce, fee, err := getBalanceAndPendingNonceAtAndGasPrice(client, address)
 	if err != nil {
 		return nil, nil, nil, err
 	}
-	return balance, nonce, gasPrice, nil
+	return balance, nonce, fee, nil
 }
 
-func getBalanceAndPendingNonceAtAndGasPrice(node *ethclient.Client, address string) (*big.Int, *uint64, *big.Int, error) {
+func getBalanceAndPendingNonceAtAndGasPrice(node *ethclient.Client, address string) (*big.Int, *uint64, *chainFee, error) {
 	ctx := context.Background()
 	balance, err := node.BalanceAt(ctx, common.HexToAddress(address), nil)
 	if err != nil {
@@ -153,15 +270,40 @@ func getBalanceAndPendingNonceAtAndGasPrice(node *ethclient.Client, address stri
 		return nil, nil, nil, errors.New(strings.Join([]string{"Failed to get account nonce from address:", address, err.Error()}, " "))
 	}
 
+	fee, err := nodeChainFee(node)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return balance, &pendingNonceAt, fee, nil
+
+}
+
+func nodeChainFee(node *ethclient.Client) (*chainFee, error) {
+	ctx := context.Background()
 	gasPrice, err := node.SuggestGasPrice(ctx)
 	if err != nil {
-		return nil, nil, nil, errors.New(strings.Join([]string{"get gasPrice error", err.Error()}, " "))
+		return nil, errors.New(strings.Join([]string{"get gasPrice error", err.Error()}, " "))
 	}
+	fee := &chainFee{gasPrice: gasPrice}
 
-	return balance, &pendingNonceAt, gasPrice, nil
+	if config.Fee.mode == "dynamic" {
+		header, err := node.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return nil, errors.New(strings.Join([]string{"get latest header error", err.Error()}, " "))
+		}
+		fee.baseFee = header.BaseFee
 
+		gasTipCap, err := node.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, errors.New(strings.Join([]string{"get gasTipCap error", err.Error()}, " "))
+		}
+		fee.gasTipCap = gasTipCap
+	}
+	return fee, nil
 }
 
+// decodeTx read legacy RLP and typed envelope, files of earlier versions wrap typed transactions in an RLP string
 func decodeTx(txHex string) (*types.Transaction, error) {
 	txc, err := hexutil.Decode(txHex)
 	if err != nil {
@@ -169,6 +311,9 @@ func decodeTx(txHex string) (*types.Transaction, error) {
 	}
 
 	var txde types.Transaction
+	if err := txde.UnmarshalBinary(txc); err == nil {
+		return &txde, nil
+	}
 
 	t, err := &txde, rlp.Decode(bytes.NewReader(txc), &txde)
 	if err != nil {
@@ -178,8 +323,9 @@ func decodeTx(txHex string) (*types.Transaction, error) {
 	return t, nil
 }
 
+// encodeTx legacy transaction as RLP list, typed transaction as type byte followed by its payload
 func encodeTx(tx *types.Transaction) (*string, error) {
-	txb, err := rlp.EncodeToBytes(tx)
+	txb, err := tx.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
@@ -193,6 +339,8 @@ func signTxCmd() {
 		log.Fatalln("read raw tx error", err.Error())
 	}
 
+	txs := []*Tx{}
+	fileNames := []string{}
 	for _, file := range files {
 		fileName := file.Name()
 		tx, err := readTxHex(&fileName, false)
@@ -200,19 +348,57 @@ func signTxCmd() {
 			log.Errorln(err.Error())
 			continue
 		}
+		txs = append(txs, tx)
+		fileNames = append(fileNames, fileName)
+	}
+
+	// dual control batches are decrypted with the operator passwords, the password media is only needed
+	// when a sender keeps its passwords in randompwd.json
+	if needPwdMedia(txs) {
+		if err := checkPwdMedia(true); err != nil {
+			log.Fatalln(err.Error())
+		}
+	}
 
+	// funding txs are confirmed once by their count and total, the total is capped by max_topup_total
+	if count, total := fundingTotal(txs); count > 0 {
+		if err := checkFundingTotal(total); err != nil {
+			log.Fatalln(err.Error())
+		}
+		promptFunding(count, formatNative(total))
+	}
+
+	for i, tx := range txs {
 		from, to, signedTxHex, hash, value, nonce, err := signTx(tx)
 		if err != nil {
 			log.Errorln(strings.Join([]string{"sign tx from", tx.From, "error", err.Error()}, " "))
 			continue
 		}
-		if err := exportHexTx(*from, *to, *signedTxHex, *hash, value, nonce, true); err != nil {
-			log.Errorln(strings.Join([]string{"export signed tx hex to", fileName, "error, issue by address:", *from, err.Error()}, " "))
+		// type, access list and token fields are checked against the signed bytes
+		signedTx := *tx
+		signedTx.From, signedTx.To, signedTx.TxHex, signedTx.Hash, signedTx.Value, signedTx.Nonce = *from, *to, *signedTxHex, *hash, *value, *nonce
+		if err := exportHexTx(&signedTx, true); err != nil {
+			log.Errorln(strings.Join([]string{"export signed tx hex to", fileNames[i], "error, issue by address:", *from, err.Error()}, " "))
 			continue
 		}
 	}
 }
 
+// needPwdMedia report whether any sender's batch reads randompwd.json, a sender without meta.json does
+func needPwdMedia(txs []*Tx) bool {
+	for _, tx := range txs {
+		timeDir, err := accountDir(tx.From)
+		if err != nil {
+			return true
+		}
+		meta, err := readAccountMeta(tx.From, *timeDir)
+		if err != nil || !meta.DualControl {
+			return true
+		}
+	}
+	return false
+}
+
 func signTx(simpletx *Tx) (*string, *string, *string, *string, *big.Int, *uint64, error) {
 	txHex := simpletx.TxHex
 	fromAddressHex := simpletx.From
@@ -221,10 +407,41 @@ func signTx(simpletx *Tx) (*string, *string, *string, *string, *big.Int, *uint64
 		return nil, nil, nil, nil, nil, nil, errors.New(strings.Join([]string{"decode tx error", err.Error()}, " "))
 	}
 
-	if Contains(config.To, tx.To().Hex()) {
-		log.Infoln("签名交易：", tx.Hash().Hex(), " To:", tx.To().Hex())
+	if err := checkTxAccessList(simpletx, tx); err != nil {
+		return nil, nil, nil, nil, nil, nil, err
+	}
+	if len(tx.AccessList()) > 0 {
+		log.Infoln("access list:", len(tx.AccessList()), "addresses, storage keys:", tx.AccessList().StorageKeys())
+	}
+
+	recipient, token, amount, err := txRecipient(tx)
+	if err != nil {
+		return nil, nil, nil, nil, nil, nil, err
+	}
+	if err := checkTxToken(simpletx, recipient, token, amount); err != nil {
+		return nil, nil, nil, nil, nil, nil, err
+	}
+	if token != nil {
+		log.Infoln(token.symbol, "transfer:", token.formatAmount(amount), "contract:", token.contract.Hex())
+	}
+
+	if simpletx.Funds != "" {
+		if err := checkFundingTx(simpletx, recipient, token, amount); err != nil {
+			return nil, nil, nil, nil, nil, nil, err
+		}
+		if err := checkDepositAddress(recipient.Hex()); err != nil {
+			return nil, nil, nil, nil, nil, nil, err
+		}
+		// confirmed with the batch total in signTxCmd
+		log.Infoln("签名充值交易：", tx.Hash().Hex(), " gas top-up", amount.String(), "To:", recipient.Hex())
+	} else if Contains(config.To, recipient.Hex()) {
+		log.Infoln("签名交易：", tx.Hash().Hex(), " To:", recipient.Hex())
 	} else {
-		promptSign(tx.To().Hex())
+		promptSign(recipient.Hex())
+	}
+
+	if err := checkSignKDFProfile(fromAddressHex); err != nil {
+		return nil, nil, nil, nil, nil, nil, err
 	}
 
 	key, err := decodeKS2Key(fromAddressHex)
@@ -233,33 +450,24 @@ func signTx(simpletx *Tx) (*string, *string, *string, *string, *big.Int, *uint64
 	}
 
 	// https://github.com/ethereum/EIPs/blob/master/EIPS/eip-155.md
-	// chain id
-	// 1 Ethereum mainnet
-	// 61 Ethereum Classic mainnet
-	// 62 Ethereum Classic testnet
-	// 1337 Geth private chains (default)
-	var chainID *big.Int
-	switch config.NetMode {
-	case "privatenet":
-		chainID = big.NewInt(1337)
-	case "mainnet":
-		chainID = big.NewInt(1)
-	default:
-		return nil, nil, nil, nil, nil, nil, errors.New("you must set net_mode in configure")
-	}
-	signtx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), key.PrivateKey)
+	// london signer signs legacy EIP-155, EIP-2930 access list and EIP-1559 dynamic fee transactions of the chain profile
+	signer := types.NewLondonSigner(chain.chainID)
+	if tx.Type() == types.DynamicFeeTxType {
+		log.Infoln("max fee per gas:", tx.GasFeeCap().String(), "max priority fee per gas:", tx.GasTipCap().String())
+	}
+	signtx, err := types.SignTx(tx, signer, key.PrivateKey)
 	if err != nil {
 		return nil, nil, nil, nil, nil, nil, errors.New(strings.Join([]string{"sign tx error", err.Error()}, " "))
 	}
-	msg, err := signtx.AsMessage(types.NewEIP155Signer(chainID))
+	sender, err := types.Sender(signer, signtx)
 	if err != nil {
-		return nil, nil, nil, nil, nil, nil, errors.New(strings.Join([]string{"tx to msg error", err.Error()}, " "))
+		return nil, nil, nil, nil, nil, nil, errors.New(strings.Join([]string{"recover tx sender error", err.Error()}, " "))
 	}
 
-	from := msg.From().Hex()
-	to := msg.To().Hex()
-	value := msg.Value()
-	nonce := msg.Nonce()
+	from := sender.Hex()
+	to := recipient.Hex()
+	value := signtx.Value()
+	nonce := signtx.Nonce()
 	signTxHex, err := encodeTx(signtx)
 	hash := signtx.Hash().Hex()
 	return &from, &to, signTxHex, &hash, value, &nonce, nil
@@ -271,19 +479,88 @@ func sendTxCmd(nodeClient *ethclient.Client) {
 		log.Fatalln("read raw tx error", err.Error())
 	}
 
+	txs := []*Tx{}
 	for _, file := range files {
 		fileName := file.Name()
 		tx, err := readTxHex(&fileName, true)
 		if err != nil {
 			log.Errorln(err.Error())
+			continue
+		}
+		txs = append(txs, tx)
+	}
+	// funding txs go first in nonce order
+	sort.SliceStable(txs, func(i, j int) bool {
+		if (txs[i].Funds != "") != (txs[j].Funds != "") {
+			return txs[i].Funds != ""
+		}
+		return txs[i].Funds != "" && txs[i].Nonce < txs[j].Nonce
+	})
+	if count, total := fundingTotal(txs); count > 0 {
+		if err := checkFundingTotal(total); err != nil {
+			log.Fatalln(err.Error())
 		}
+	}
 
-		signedTxHex := tx.TxHex
-		hash, err := sendTx(signedTxHex, nodeClient)
+	// a funding tx is only sent for the address whose signed token transfers use it
+	funds := map[string]string{}
+	for _, tx := range txs {
+		if tx.FundedBy != "" {
+			funds[fundingKey(tx.FundedBy, tx.FundingNonce)] = tx.From
+		}
+	}
+
+	// hash of the funding txs sent by this run, or sent by an earlier run and known to the node
+	funding := map[string]string{}
+	funded := []*Tx{}
+	for _, tx := range txs {
+		if tx.FundedBy != "" {
+			funded = append(funded, tx)
+			continue
+		}
+		key := fundingKey(tx.From, tx.Nonce)
+		if tx.Funds != "" && !strings.EqualFold(funds[key], tx.Funds) {
+			log.Errorln("send tx: ", tx.From, "fund", tx.Funds, "fail", "no signed token transfer of", tx.Funds, "uses it")
+			continue
+		}
+		hash, err := sendTx(tx, nodeClient)
 		if err != nil {
-			log.Errorln("send tx: ", fileName, "fail", err.Error())
+			if tx.Funds != "" {
+				if known, ok := sentFundingTx(nodeClient, tx); ok {
+					log.Infoln("funding tx", known, "of", tx.Funds, "already sent")
+					funding[key] = known
+					continue
+				}
+			}
+			log.Errorln("send tx: ", tx.From, tx.Token, "fail", err.Error())
+			continue
+		}
+		log.Infoln("send tx: ", explorerTxURL(*hash), "success")
+		if tx.Funds != "" {
+			funding[key] = *hash
+		}
+	}
+
+	// token transfers are sent after their gas top-up is mined, all funding txs are waited for at once
+	if len(funding) > 0 {
+		log.Infoln("wait", len(funding), "funding txs, at most", fundingWait.String())
+	}
+	mined := waitFundingTxs(nodeClient, funding, fundingWait)
+	for _, tx := range funded {
+		key := fundingKey(tx.FundedBy, tx.FundingNonce)
+		if _, ok := funding[key]; !ok {
+			log.Errorln("send tx: ", tx.From, tx.Token, "fail", "funding tx of nonce", tx.FundingNonce, "not sent")
+			continue
+		}
+		if err := mined[key]; err != nil {
+			log.Errorln("send tx: ", tx.From, tx.Token, "fail", err.Error())
+			continue
+		}
+		hash, err := sendTx(tx, nodeClient)
+		if err != nil {
+			log.Errorln("send tx: ", tx.From, tx.Token, "fail", err.Error())
 		} else {
-			log.Infoln("send tx: ", *hash, "success")
+			log.Infoln("send tx: ", explorerTxURL(*hash), "success")
 		}
 	}
 }
@@ -308,14 +585,29 @@ func readTxHex(fileName *string, signed bool) (*Tx, error) {
 	return &tx, nil
 }
 
-func sendTx(signTxHex string, nodeClient *ethclient.Client) (*string, error) {
-	signTx, err := decodeTx(signTxHex)
+func sendTx(simpletx *Tx, nodeClient *ethclient.Client) (*string, error) {
+	signTx, err := decodeTx(simpletx.TxHex)
 	if err != nil {
 		return nil, errors.New(strings.Join([]string{"Send tx error:", "decode tx error", err.Error()}, " "))
 	}
 
-	if !Contains(config.To, signTx.To().Hex()) {
-		return nil, errors.New(strings.Join([]string{"Send tx error: ", signTx.To().Hex(), "is not contained in configure to value"}, " "))
+	recipient, token, amount, err := txRecipient(signTx)
+	if err != nil {
+		return nil, errors.New(strings.Join([]string{"Send tx error:", err.Error()}, " "))
+	}
+	if simpletx.Funds != "" {
+		sender, err := types.Sender(types.NewLondonSigner(chain.chainID), signTx)
+		if err != nil {
+			return nil, errors.New(strings.Join([]string{"Send tx error:", "recover tx sender error", err.Error()}, " "))
+		}
+		if !strings.EqualFold(sender.Hex(), simpletx.From) {
+			return nil, errors.New(strings.Join([]string{"Send tx error:", "funding tx sender", sender.Hex(), "does not match", simpletx.From}, " "))
+		}
+		if err := checkFundingTx(simpletx, recipient, token, amount); err != nil {
+			return nil, errors.New(strings.Join([]string{"Send tx error:", err.Error()}, " "))
+		}
+	} else if !Contains(config.To, recipient.Hex()) {
+		return nil, errors.New(strings.Join([]string{"Send tx error: ", recipient.Hex(), "is not contained in configure to value"}, " "))
 	}
 
 	if err := nodeClient.SendTransaction(context.Background(), signTx); err != nil {
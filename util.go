@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -11,7 +12,10 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"path"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -30,6 +34,11 @@ func HomeDir() string {
 	return home
 }
 
+func configExists() bool {
+	_, err := os.Stat(strings.Join([]string{HomeDir(), "ethereum-cold-wallet.yml"}, "/"))
+	return err == nil
+}
+
 func initLogger() {
 	path := strings.Join([]string{HomeDir(), ".ethereum_service"}, "/")
 	if err := os.MkdirAll(path, 0700); err != nil {
@@ -50,9 +59,35 @@ func initLogger() {
 	}
 }
 
+// auditLog append a json line for sensitive operation to ~/.ethereum_service/audit.log
+func auditLog(action string, fields log.Fields) error {
+	entry := log.Fields{
+		"action": action,
+		"time":   time.Now().Format(time.RFC3339),
+	}
+	for k, v := range fields {
+		entry[k] = v
+	}
+	if user := os.Getenv("USER"); user != "" {
+		entry["user"] = user
+	}
+
+	bEntry, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	bEntry = append(bEntry, '\n')
+	auditFile := strings.Join([]string{HomeDir(), ".ethereum_service", "audit.log"}, "/")
+	return appenFile(auditFile, bEntry, 0600)
+}
+
 func promptPwd() (*string, error) {
+	return promptLabeledPwd("Password")
+}
+
+func promptLabeledPwd(label string) (*string, error) {
 	promptOne := promptui.Prompt{
-		Label: "Password",
+		Label: label,
 		Mask:  '*',
 	}
 
@@ -70,7 +105,7 @@ func promptPwd() (*string, error) {
 	}
 
 	promptTwo := promptui.Prompt{
-		Label:    "Password",
+		Label:    label,
 		Validate: validate,
 		Mask:     '*',
 	}
@@ -95,31 +130,29 @@ func promptSign(to string) {
 	}
 }
 
-// RandStringBytesMaskImprSrc 随机数
-// https://stackoverflow.com/questions/22892120/how-to-generate-a-random-string-of-a-fixed-length-in-golang
-func RandStringBytesMaskImprSrc(n int) string {
-	var src = rand.NewSource(time.Now().UnixNano())
-	const letterBytes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
-	const (
-		letterIdxBits = 6                    // 6 bits to represent a letter index
-		letterIdxMask = 1<<letterIdxBits - 1 // All 1-bits, as many as letterIdxBits
-		letterIdxMax  = 63 / letterIdxBits   // # of letter indices fitting in 63 bits
-	)
-	b := make([]byte, n)
-	// A src.Int63() generates 63 random bits, enough for letterIdxMax characters!
-	for i, cache, remain := n-1, src.Int63(), letterIdxMax; i >= 0; {
-		if remain == 0 {
-			cache, remain = src.Int63(), letterIdxMax
-		}
-		if idx := int(cache & letterIdxMask); idx < len(letterBytes) {
-			b[i] = letterBytes[idx]
-			i--
-		}
-		cache >>= letterIdxBits
-		remain--
+// promptFunding confirm the funding txs of a sign run once, by their count and total
+func promptFunding(count int, total string) {
+	prompt := promptui.Prompt{
+		Label:     strings.Join([]string{"签名", strconv.Itoa(count), "笔 gas_station 充值交易，共", total, "，请确认"}, " "),
+		IsConfirm: true,
 	}
 
-	return string(b)
+	_, err := prompt.Run()
+	if err != nil {
+		log.Fatalln("退出...")
+	}
+}
+
+func promptMedium(pwdType, root string) {
+	prompt := promptui.Prompt{
+		Label:     strings.Join([]string{"请挂载", pwdType, "密码介质到", root, "并确认"}, " "),
+		IsConfirm: true,
+	}
+
+	_, err := prompt.Run()
+	if err != nil {
+		log.Fatalln("退出...")
+	}
 }
 
 // PKCS7Padding PKCS7 填充 https://www.jianshu.com/p/b63095c59361
@@ -130,13 +163,24 @@ func PKCS7Padding(ciphertext []byte, blockSize int) []byte {
 }
 
 // PKCS7UnPadding 还原 PKCS7 填充
-func PKCS7UnPadding(origData []byte) []byte {
+func PKCS7UnPadding(origData []byte, blockSize int) ([]byte, error) {
 	length := len(origData)
+	if length == 0 || length%blockSize != 0 {
+		return nil, errors.New("invalid PKCS7 data length")
+	}
 	unpadding := int(origData[length-1])
-	return origData[:(length - unpadding)]
+	if unpadding == 0 || unpadding > blockSize {
+		return nil, errors.New("invalid PKCS7 padding")
+	}
+	for _, b := range origData[length-unpadding:] {
+		if int(b) != unpadding {
+			return nil, errors.New("invalid PKCS7 padding")
+		}
+	}
+	return origData[:(length - unpadding)], nil
 }
 
-// AesEncrypt 加密
+// AesEncrypt 加密，version 1 二维码格式，IV 取自 key，仅用于兼容
 func AesEncrypt(origData, key []byte) ([]byte, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
@@ -157,22 +201,27 @@ func AesDecrypt(crypted, key []byte) ([]byte, error) {
 		return nil, err
 	}
 	blockSize := block.BlockSize()
+	if len(crypted) == 0 || len(crypted)%blockSize != 0 {
+		return nil, errors.New("crypted data is not a multiple of the block size")
+	}
 	blockMode := cipher.NewCBCDecrypter(block, key[:blockSize])
 	origData := make([]byte, len(crypted))
 	blockMode.CryptBlocks(origData, crypted)
-	origData = PKCS7UnPadding(origData)
-	return origData, nil
+	return PKCS7UnPadding(origData, blockSize)
 }
 
-func nodeClient(node string) (*ethclient.Client, error) {
+func nodeRPC(node string) string {
 	var nodeConfig string
 	if node == "geth" {
 		nodeConfig = config.GethRPC
 	} else if node == "parity" {
 		nodeConfig = config.ParityRPC
 	}
+	return nodeConfig
+}
 
-	client, err := ethclient.Dial(nodeConfig)
+func nodeClient(node string) (*ethclient.Client, error) {
+	client, err := ethclient.Dial(nodeRPC(node))
 	if err != nil {
 		return nil, errors.New(strings.Join([]string{"node error", err.Error()}, " "))
 	}
@@ -181,8 +230,8 @@ func nodeClient(node string) (*ethclient.Client, error) {
 
 func balanceIsLessThanConfig(address string, balance *big.Int) error {
 	balanceDecimal, _ := decimal.NewFromString(balance.String())
-	ethFac, _ := decimal.NewFromString("0.000000000000000001")
-	amount := balanceDecimal.Mul(ethFac)
+	// native currency decimals of the chain
+	amount := balanceDecimal.Mul(decimal.New(1, -chain.decimals))
 	settingBalance := decimal.NewFromFloat(config.MaxBalance)
 	if amount.LessThan(settingBalance) {
 		return errors.New(strings.Join([]string{"Ignore:", address, "balance not great than the configure amount"}, " "))
@@ -190,7 +239,13 @@ func balanceIsLessThanConfig(address string, balance *big.Int) error {
 	return nil
 }
 
+// appendMu serialize appenFile, concurrent genaccount workers append to the same randompwd.json and meta.json
+var appendMu sync.Mutex
+
 func appenFile(filename string, data []byte, perm os.FileMode) error {
+	appendMu.Lock()
+	defer appendMu.Unlock()
+
 	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, perm)
 	if err != nil {
 		return err
@@ -205,6 +260,56 @@ func appenFile(filename string, data []byte, perm os.FileMode) error {
 	return err
 }
 
+// writeFileAtomic write data to a temporary file in the same directory then rename it over filename
+func writeFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	f, err := ioutil.TempFile(path.Dir(filename), "."+path.Base(filename))
+	if err != nil {
+		return err
+	}
+	tmpName := f.Name()
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return os.Rename(tmpName, filename)
+}
+
+func copyFile(src, dst string) error {
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dst, data, 0600)
+}
+
+// removeAll overwrite regular files with zeros before removing the directory, used for password backups
+func removeAll(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.Mode().IsRegular() {
+			ioutil.WriteFile(strings.Join([]string{dir, f.Name()}, "/"), make([]byte, f.Size()), 0600)
+		}
+	}
+	return os.RemoveAll(dir)
+}
+
 func mkdirBySlice(slice []string) (*string, error) {
 	path := strings.Join(slice, "/")
 	if _, err := os.Stat(path); os.IsNotExist(err) {
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	log "github.com/sirupsen/logrus"
+)
+
+// vanityPattern 靓号地址匹配规则，十六进制前缀和后缀，忽略大小写
+type vanityPattern struct {
+	prefix string
+	suffix string
+}
+
+// vanityMatch fresh mnemonic whose first account matches the pattern
+type vanityMatch struct {
+	mnemonic string
+	account  *derivedAccount
+}
+
+func newVanityPattern(prefix, suffix string) (*vanityPattern, error) {
+	prefix = strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(prefix, "0x"), "0X"))
+	suffix = strings.ToLower(suffix)
+	for _, part := range []string{prefix, suffix} {
+		for _, c := range part {
+			if !strings.ContainsRune("0123456789abcdef", c) {
+				return nil, errors.New(strings.Join([]string{"vanity pattern", part, "is not hex"}, " "))
+			}
+		}
+	}
+	if len(prefix)+len(suffix) > common.AddressLength*2 {
+		return nil, errors.New("vanity pattern longer than address")
+	}
+	return &vanityPattern{prefix, suffix}, nil
+}
+
+func (p *vanityPattern) match(address common.Address) bool {
+	hexAddress := hex.EncodeToString(address.Bytes())
+	return strings.HasPrefix(hexAddress, p.prefix) && strings.HasSuffix(hexAddress, p.suffix)
+}
+
+// difficulty expected attempts to find one match
+func (p *vanityPattern) difficulty() float64 {
+	return math.Pow(16, float64(len(p.prefix)+len(p.suffix)))
+}
+
+// vanityProgress attempts, rate and estimated time of the search
+func vanityProgress(pattern *vanityPattern, attempts *uint64, found func() int, count int) func(time.Duration) log.Fields {
+	return func(elapsed time.Duration) log.Fields {
+		tried := atomic.LoadUint64(attempts)
+		rate := float64(tried) / elapsed.Seconds()
+		fields := log.Fields{
+			"attempts":   tried,
+			"found":      found(),
+			"wanted":     count,
+			"rate":       int(rate),
+			"difficulty": pattern.difficulty(),
+		}
+		if rate > 0 {
+			fields["estimated"] = etaString(float64(count-found()) * pattern.difficulty() / rate)
+		}
+		return fields
+	}
+}
+
+// createVanitySeedAccounts search derivation indexes of one mnemonic with workers until count accounts match
+func createVanitySeedAccounts(accoutDir, timeDir, template, passphrase string, pattern *vanityPattern, count, workers int) ([]string, error) {
+	if !isIndexedDerivationPath(template) {
+		return nil, errors.New(strings.Join([]string{"derivation path", template, "has no index component x"}, " "))
+	}
+
+	mnemonic, err := mnemonicFun(mnemonicBits)
+	if err != nil {
+		return nil, err
+	}
+	masterKey, err := hdMasterKey(*mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		nextIndex uint64
+		attempts  uint64
+		mu        sync.Mutex
+		accounts  []*derivedAccount
+		searchErr error
+		wg        sync.WaitGroup
+		done      = make(chan struct{})
+		once      sync.Once
+	)
+	found := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(accounts)
+	}
+	stop := func() { once.Do(func() { close(done) }) }
+
+	go reportProgress("searching vanity address", done, vanityProgress(pattern, &attempts, found, count))
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				index := atomic.AddUint64(&nextIndex, 1) - 1
+				if index >= hdkeychain.HardenedKeyStart {
+					mu.Lock()
+					searchErr = errors.New("vanity search exhausted derivation indexes")
+					mu.Unlock()
+					stop()
+					return
+				}
+				privateKey, path, err := deriveHDKey(masterKey, template, uint32(index))
+				atomic.AddUint64(&attempts, 1)
+				if err != nil {
+					// invalid child key, try next index
+					continue
+				}
+
+				address := crypto.PubkeyToAddress(privateKey.PublicKey)
+				if !pattern.match(address) {
+					continue
+				}
+				mu.Lock()
+				if len(accounts) < count {
+					accounts = append(accounts, &derivedAccount{privateKey, address.Hex(), uint32(index), *path})
+					log.Infoln("found vanity address", address.Hex(), "at", *path)
+				}
+				if len(accounts) >= count {
+					stop()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	stop()
+	if searchErr != nil {
+		return nil, searchErr
+	}
+
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].index < accounts[j].index })
+	if err := saveSeedAccounts(*mnemonic, accounts, template, passphrase, accoutDir, timeDir, workers); err != nil {
+		return nil, err
+	}
+
+	addresses := []string{}
+	for _, account := range accounts {
+		addresses = append(addresses, account.address)
+	}
+	return addresses, nil
+}
+
+// createVanityAccounts search fresh mnemonics with workers until count first accounts match
+func createVanityAccounts(accoutDir, timeDir, template, passphrase string, pattern *vanityPattern, count, workers int) ([]string, error) {
+	var (
+		attempts uint64
+		mu       sync.Mutex
+		matches  []*vanityMatch
+		errs     []error
+		wg       sync.WaitGroup
+		done     = make(chan struct{})
+		once     sync.Once
+	)
+	found := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(matches)
+	}
+	stop := func() { once.Do(func() { close(done) }) }
+
+	go reportProgress("searching vanity address", done, vanityProgress(pattern, &attempts, found, count))
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				mnemonic, err := mnemonicFun(mnemonicBits)
+				if err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+					stop()
+					return
+				}
+				key, keyPath, err := hdWallet(*mnemonic, passphrase, template, 0)
+				atomic.AddUint64(&attempts, 1)
+				if err != nil {
+					continue
+				}
+
+				address := crypto.PubkeyToAddress(key.PublicKey)
+				if !pattern.match(address) {
+					continue
+				}
+				mu.Lock()
+				if len(matches) < count {
+					matches = append(matches, &vanityMatch{*mnemonic, &derivedAccount{key, address.Hex(), 0, *keyPath}})
+					log.Infoln("found vanity address", address.Hex())
+				}
+				if len(matches) >= count {
+					stop()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	stop()
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	addresses := []string{}
+	for _, match := range matches {
+		if err := saveSeedAccount(match.mnemonic, match.account, template, passphrase, accoutDir, timeDir); err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, match.account.address)
+	}
+	return addresses, nil
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// accountCheck 账户完整性检查结果
+type accountCheck struct {
+	Address        string `json:"address"`
+	Batch          string `json:"batch"`
+	Keystore       bool   `json:"keystore"`
+	FirstPwd       bool   `json:"first_pwd"`
+	SecondPwd      bool   `json:"second_pwd"`
+	MnemonicQrcode bool   `json:"mnemonic_qrcode"`
+	KeyQrcode      bool   `json:"key_qrcode"`
+	Decrypt        bool   `json:"decrypt"`
+	InCSV          bool   `json:"in_csv"`
+	// Manifest ok, none for batches without manifest.json, - on differences
+	Manifest    string   `json:"manifest"`
+	DualControl bool     `json:"dual_control,omitempty"`
+	Errors      []string `json:"errors,omitempty"`
+}
+
+func (c *accountCheck) ok() bool {
+	return len(c.Errors) == 0
+}
+
+func (c *accountCheck) fail(msg string) {
+	c.Errors = append(c.Errors, msg)
+}
+
+// verifyAccountCmd walk every version directory of ~/account/keystore (or only batch) and check the whole set
+// of each account, batches with manifest.json are also checked against it
+func verifyAccountCmd(format, batch string) {
+	if format != "table" && format != "json" {
+		log.Fatalln("Only support format table, json")
+	}
+
+	csvAddresses := map[string]bool{}
+	addresses, err := readCSVAddresses(strings.Join([]string{HomeDir(), "account"}, "/"))
+	if err != nil {
+		log.Warnln("read eth_address.csv error", err.Error())
+	}
+	for _, address := range addresses {
+		csvAddresses[strings.ToLower(address.Address)] = false
+	}
+
+	ksPath := strings.Join([]string{HomeDir(), "account", "keystore"}, "/")
+	timeFolders, err := ioutil.ReadDir(ksPath)
+	if err != nil {
+		log.Fatalln("Get keystore directory error", err.Error())
+	}
+
+	checks := []*accountCheck{}
+	for _, timeFolder := range timeFolders {
+		if !timeFolder.IsDir() {
+			continue
+		}
+		timeDir := timeFolder.Name()
+		if batch != "" && timeDir != batch {
+			continue
+		}
+		files, err := ioutil.ReadDir(strings.Join([]string{ksPath, timeDir}, "/"))
+		if err != nil {
+			log.Fatalln("Get timeDir error", err.Error())
+		}
+
+		manifest, manifestErr := readBatchManifest(timeDir)
+		manifestAccounts := map[string]*ManifestAccount{}
+		batchProblems := []string{}
+		if manifest != nil {
+			for _, account := range manifest.Accounts {
+				manifestAccounts[strings.ToLower(account.Address)] = account
+			}
+			// meta.json and randompwd.json are shared by the batch, a difference fails every account of it
+			batchProblems = verifyManifestBatch(manifest, timeDir)
+		}
+
+		for _, f := range files {
+			address := strings.TrimSuffix(f.Name(), ".json")
+			if !strings.HasPrefix(address, "0x") {
+				continue
+			}
+			check := verifyAccount(address, timeDir)
+			if _, ok := csvAddresses[strings.ToLower(address)]; ok {
+				check.InCSV = true
+				csvAddresses[strings.ToLower(address)] = true
+			} else {
+				check.fail("not in eth_address.csv")
+			}
+
+			switch {
+			case manifest != nil:
+				entry, ok := manifestAccounts[strings.ToLower(address)]
+				if !ok {
+					check.Manifest = "-"
+					check.fail("not listed in manifest")
+					break
+				}
+				delete(manifestAccounts, strings.ToLower(address))
+				problems := append(verifyManifestAccount(entry, timeDir), batchProblems...)
+				for _, problem := range problems {
+					check.fail(problem)
+				}
+				check.Manifest = "ok"
+				if len(problems) > 0 {
+					check.Manifest = "-"
+				}
+			case os.IsNotExist(manifestErr):
+				// batches generated before manifest.json
+				check.Manifest = "none"
+			default:
+				check.Manifest = "-"
+				check.fail(strings.Join([]string{"read manifest error:", manifestErr.Error()}, " "))
+			}
+			checks = append(checks, check)
+		}
+
+		// accounts listed in manifest but without keystore, sorted to keep the report stable
+		missing := []string{}
+		for address := range manifestAccounts {
+			missing = append(missing, address)
+		}
+		sort.Strings(missing)
+		for _, address := range missing {
+			account := manifestAccounts[address]
+			check := &accountCheck{Address: account.Address, Batch: timeDir, Manifest: "-"}
+			check.fail("keystore missing, listed in manifest")
+			checks = append(checks, check)
+		}
+	}
+
+	// addresses exported to csv but without keystore
+	for _, address := range addresses {
+		if batch == "" && !csvAddresses[strings.ToLower(address.Address)] {
+			check := &accountCheck{Address: address.Address, InCSV: true}
+			check.fail("keystore not found")
+			checks = append(checks, check)
+		}
+	}
+
+	failed := 0
+	for _, check := range checks {
+		if !check.ok() {
+			failed++
+		}
+	}
+
+	if format == "json" {
+		bChecks, err := json.MarshalIndent(checks, "", "  ")
+		if err != nil {
+			log.Fatalln(err.Error())
+		}
+		fmt.Println(string(bChecks))
+	} else {
+		printAccountChecks(checks)
+	}
+
+	if failed > 0 {
+		log.Errorln(failed, "of", len(checks), "accounts failed verification")
+		os.Exit(1)
+	}
+	log.Infoln(len(checks), "accounts verified")
+}
+
+func verifyAccount(address, timeDir string) *accountCheck {
+	check := &accountCheck{Address: address, Batch: timeDir, Keystore: true}
+
+	// accounts generated before meta.json own their qrcode backup
+	seed := address
+	meta, err := readAccountMeta(address, timeDir)
+	if err == nil {
+		seed = meta.Seed
+	}
+
+	if meta != nil && meta.DualControl {
+		// password halves are held by operators, keystore is not decrypted and reported as skipped
+		check.DualControl = true
+	} else {
+		if pwd, err := readPwd(address, "random_pwd_first", timeDir); err != nil || *pwd == "" {
+			check.fail("random_pwd_first entry missing")
+		} else {
+			check.FirstPwd = true
+		}
+		if pwd, err := readPwd(address, "random_pwd_second", timeDir); err != nil || *pwd == "" {
+			check.fail("random_pwd_second entry missing")
+		} else {
+			check.SecondPwd = true
+		}
+	}
+	if meta != nil && meta.Imported {
+		check.MnemonicQrcode, check.KeyQrcode = true, true
+	} else {
+		check.MnemonicQrcode, check.KeyQrcode = verifyQrcodeFiles(seed, timeDir)
+		if !check.MnemonicQrcode {
+			check.fail("mnemonic qrcode missing")
+		}
+		if !check.KeyQrcode {
+			check.fail("key qrcode missing")
+		}
+	}
+
+	if check.FirstPwd && check.SecondPwd && !check.DualControl {
+		key, err := decodeKS2KeyInDir(address, timeDir)
+		if err != nil {
+			check.fail(strings.Join([]string{"decrypt keystore error:", err.Error()}, " "))
+		} else if !strings.EqualFold(key.Address.Hex(), address) {
+			check.fail(strings.Join([]string{"keystore address mismatch:", key.Address.Hex()}, " "))
+		} else {
+			check.Decrypt = true
+		}
+	}
+	return check
+}
+
+// verifyQrcodeFiles tells whether the mnemonic qrcode and the key qrcode (or its shares) exist
+func verifyQrcodeFiles(seed, timeDir string) (bool, bool) {
+	qrcodePath := strings.Join([]string{HomeDir(), "account", "mnemonic_qrcode", timeDir, seed}, "/")
+	mnemonicPNG := strings.Join([]string{qrcodePath, strings.Join([]string{seed, "aesdecrypt_mnemonic_marked.png"}, "_")}, "/")
+	keyPNG := strings.Join([]string{qrcodePath, strings.Join([]string{seed, "aesdecrypt_key_marked.png"}, "_")}, "/")
+
+	_, err := os.Stat(mnemonicPNG)
+	mnemonicOk := err == nil
+	_, err = os.Stat(keyPNG)
+	keyOk := err == nil
+	if !keyOk {
+		shares, _ := filepath.Glob(strings.Join([]string{qrcodePath, strings.Join([]string{seed, "aesdecrypt_key_share_*_marked.png"}, "_")}, "/"))
+		keyOk = len(shares) > 0
+	}
+	return mnemonicOk, keyOk
+}
+
+func printAccountChecks(checks []*accountCheck) {
+	mark := func(b bool) string {
+		if b {
+			return "ok"
+		}
+		return "-"
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ADDRESS\tBATCH\tKEYSTORE\tPWD1\tPWD2\tMNEMONIC\tKEY\tDECRYPT\tCSV\tMANIFEST\tERRORS")
+	for _, c := range checks {
+		firstPwd, secondPwd, decrypt := mark(c.FirstPwd), mark(c.SecondPwd), mark(c.Decrypt)
+		if c.DualControl {
+			firstPwd, secondPwd, decrypt = "operator", "operator", "skipped"
+		}
+		fmt.Fprintln(w, strings.Join([]string{
+			c.Address, c.Batch, mark(c.Keystore), firstPwd, secondPwd,
+			mark(c.MnemonicQrcode), mark(c.KeyQrcode), decrypt, mark(c.InCSV), c.Manifest,
+			strings.Join(c.Errors, "; "),
+		}, "\t"))
+	}
+	w.Flush()
+}
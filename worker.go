@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// maxDefaultWorkers default worker count, a standard scrypt keystore needs 256MB
+const maxDefaultWorkers = 4
+
+// defaultWorkers CPUs up to maxDefaultWorkers, capped by the memory the KDF profile needs
+func defaultWorkers(profile *kdfProfile) int {
+	n := runtime.NumCPU()
+	if n > maxDefaultWorkers {
+		n = maxDefaultWorkers
+	}
+	if limit := memoryWorkers(profile); limit > 0 && limit < n {
+		n = limit
+	}
+	return n
+}
+
+// memoryWorkers how many keystore KDF of the profile fit in half of the available memory, 0 if unknown
+func memoryWorkers(profile *kdfProfile) int {
+	need := profile.memory()
+	available, err := availableMemory()
+	if need == 0 || err != nil {
+		return 0
+	}
+	if n := int(available / 2 / need); n > 1 {
+		return n
+	}
+	return 1
+}
+
+// availableMemory MemAvailable of /proc/meminfo in bytes
+func availableMemory() (uint64, error) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemAvailable:" {
+			kb, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			return kb * 1024, nil
+		}
+	}
+	return 0, errors.New("MemAvailable not found in /proc/meminfo")
+}
+
+// runParallel run task for index 0 to count-1 on workers goroutines, stop at the first error.
+// Progress and estimated time are logged every 10 seconds.
+func runParallel(label string, count, workers int, task func(index int) error) error {
+	if workers > count {
+		workers = count
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	var (
+		next     uint64
+		finished uint64
+		failed   int32
+		taskErr  error
+		errOnce  sync.Once
+		wg       sync.WaitGroup
+	)
+	done := make(chan struct{})
+	go reportProgress(label, done, func(elapsed time.Duration) log.Fields {
+		n := atomic.LoadUint64(&finished)
+		fields := log.Fields{
+			"finished": n,
+			"total":    count,
+			"elapsed":  elapsed.Truncate(time.Second).String(),
+		}
+		if n > 0 {
+			fields["estimated"] = etaString(elapsed.Seconds() / float64(n) * float64(uint64(count)-n))
+		}
+		return fields
+	})
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.LoadInt32(&failed) == 0 {
+				index := int(atomic.AddUint64(&next, 1) - 1)
+				if index >= count {
+					return
+				}
+				if err := task(index); err != nil {
+					errOnce.Do(func() {
+						taskErr = err
+						atomic.StoreInt32(&failed, 1)
+					})
+					return
+				}
+				atomic.AddUint64(&finished, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+	return taskErr
+}
+
+// maxETA longer estimated times are shown clamped, a duration overflows beyond 290 years
+const maxETA = 100 * 365 * 24 * time.Hour
+
+// reportProgress log the fields returned by progress every 10 seconds until done is closed
+func reportProgress(label string, done chan struct{}, progress func(elapsed time.Duration) log.Fields) {
+	start := time.Now()
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			log.WithFields(progress(time.Since(start))).Info(label)
+		}
+	}
+}
+
+// etaString remaining seconds as duration, clamped to maxETA
+func etaString(seconds float64) string {
+	if seconds >= maxETA.Seconds() {
+		return "more than 100 years"
+	}
+	return time.Duration(seconds * float64(time.Second)).Truncate(time.Second).String()
+}
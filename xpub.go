@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/ethereum/go-ethereum/crypto"
+	log "github.com/sirupsen/logrus"
+)
+
+// XpubJSON 账户层扩展公钥，在线端据此派生监听地址
+type XpubJSON struct {
+	Seed string `json:"seed"`
+	Xpub string `json:"xpub"`
+	// PATH account level path of the xpub, Template the non-hardened rest, x is the address index
+	PATH     string `json:"path"`
+	Template string `json:"template"`
+	Count    int    `json:"count"`
+	Batch    string `json:"batch"`
+}
+
+// xpubAddress 由扩展公钥派生的地址
+type xpubAddress struct {
+	address string
+	index   uint32
+	path    string
+}
+
+// splitXpubTemplate split the derivation template at the last hardened component,
+// the account level part is derived on the cold side, the rest from the xpub
+func splitXpubTemplate(template string) (string, string, error) {
+	components := strings.Split(strings.TrimSpace(template), "/")
+	last := 0
+	for i, component := range components {
+		if strings.HasSuffix(component, "H") || strings.HasSuffix(component, "h") || strings.HasSuffix(component, "'") {
+			last = i
+		}
+	}
+
+	relative := strings.Join(components[last+1:], "/")
+	if relative == "" || !isIndexedDerivationPath(relative) {
+		return "", "", errors.New(strings.Join([]string{"derivation path", template, "has no non-hardened index component x, could not export xpub"}, " "))
+	}
+	return strings.Join(components[:last+1], "/"), relative, nil
+}
+
+// saveXpub export the account level xpub of the mnemonic to account/xpub/<batch>/<seed>_xpub.json
+func saveXpub(mnemonic, passphrase, template, seed string, count int, dir, timeDir string) error {
+	accountTemplate, relative, err := splitXpubTemplate(template)
+	if err != nil {
+		return err
+	}
+
+	masterKey, err := hdMasterKey(mnemonic, passphrase)
+	if err != nil {
+		return err
+	}
+	accountPath := &accountTemplate
+	children := []uint32{}
+	if accountTemplate != "m" {
+		children, accountPath, err = parseDerivationPath(accountTemplate, 0)
+		if err != nil {
+			return err
+		}
+	}
+	key := masterKey
+	for _, child := range children {
+		key, err = key.Child(child)
+		if err != nil {
+			return err
+		}
+	}
+	pubKey, err := key.Neuter()
+	if err != nil {
+		return err
+	}
+
+	bXpubJSON, err := json.Marshal(&XpubJSON{
+		Seed:     seed,
+		Xpub:     pubKey.String(),
+		PATH:     *accountPath,
+		Template: relative,
+		Count:    count,
+		Batch:    timeDir,
+	})
+	if err != nil {
+		return err
+	}
+	xpubPath, err := mkdirBySlice([]string{dir, "xpub", timeDir})
+	if err != nil {
+		return errors.New(strings.Join([]string{"Could not create directory", err.Error()}, " "))
+	}
+	xpubFile := strings.Join([]string{*xpubPath, strings.Join([]string{seed, "xpub.json"}, "_")}, "/")
+	if err := writeFileAtomic(xpubFile, bXpubJSON, 0600); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"Export xpub": xpubFile,
+		"Path":        *accountPath,
+		"Time:":       time.Now().Format("Mon Jan _2 15:04:05 2006"),
+	}).Info("")
+	return nil
+}
+
+func readXpubFile(file string) (*XpubJSON, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var x XpubJSON
+	if err := json.Unmarshal(data, &x); err != nil {
+		return nil, errors.New(strings.Join([]string{"invalid xpub file", file, err.Error()}, " "))
+	}
+	return &x, nil
+}
+
+// deriveXpubAddresses derive count watch-only addresses from index from, template is relative to the xpub
+func deriveXpubAddresses(xpub, accountPath, template string, from, count uint32) ([]*xpubAddress, error) {
+	key, err := hdkeychain.NewKeyFromString(xpub)
+	if err != nil {
+		return nil, err
+	}
+	if key.IsPrivate() {
+		return nil, errors.New("refuse extended private key on the online side, export the xpub")
+	}
+	if !isIndexedDerivationPath(template) {
+		return nil, errors.New(strings.Join([]string{"derivation path", template, "has no index component x"}, " "))
+	}
+
+	addresses := []*xpubAddress{}
+	for index := from; index < from+count; index++ {
+		children, path, err := parseDerivationPath(strings.Join([]string{"m", template}, "/"), index)
+		if err != nil {
+			return nil, err
+		}
+		child := key
+		for _, c := range children {
+			// public derivation fails on hardened components
+			child, err = child.Child(c)
+			if err != nil {
+				return nil, err
+			}
+		}
+		pubKey, err := child.ECPubKey()
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, &xpubAddress{
+			address: crypto.PubkeyToAddress(*pubKey.ToECDSA()).Hex(),
+			index:   index,
+			path:    strings.Join([]string{accountPath, strings.TrimPrefix(*path, "m/")}, "/"),
+		})
+	}
+	return addresses, nil
+}
+
+func deriveXpubCmd(file, xpub, template string, from, count uint32) {
+	accountPath := "m"
+	if file != "" {
+		x, err := readXpubFile(file)
+		if err != nil {
+			log.Fatalln(err.Error())
+		}
+		xpub, accountPath, template = x.Xpub, x.PATH, x.Template
+	}
+	if xpub == "" {
+		log.Fatalln("xpub or xpub file is required")
+	}
+	if chain != nil {
+		// a bare --xpub has no account path to check
+		if file == "" {
+			log.Warnln("coin type of --xpub can not be checked against chain", chain.name, "use --file")
+		} else if err := checkCoinType(accountPath, chain.coinType); err != nil {
+			log.Fatalln(err.Error())
+		}
+	}
+
+	addresses, err := deriveXpubAddresses(xpub, accountPath, template, from, count)
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+
+	ormDB := ormBbAlias{dbConn()}
+	ormDB.DBMigrate()
+	defer ormDB.Close()
+	ormDB.xpub2db(xpub, addresses)
+}